@@ -1,166 +1,505 @@
-package main
-
-import (
-	"context"
-	"flag"
-	"fmt"
-	"log"
-	"net/http"
-	"os"
-	"os/signal"
-	"sync"
-	"time"
-
-	"web-service/config"
-	googledrive "web-service/pkg/google-drive"
-	"web-service/pkg/handler"
-	"web-service/pkg/kafka"
-	"web-service/pkg/middlewares"
-
-	"github.com/gorilla/mux"
-)
-
-type ServerConfig struct {
-	Host          string
-	Port          string
-	ReadTimeout   time.Duration
-	WriteTimeout  time.Duration
-	IdleTimeout   time.Duration
-	ShutdownDelay time.Duration
-}
-
-var wg sync.WaitGroup
-
-func loadEnv() {
-	if err := config.Load(); err != nil {
-		log.Fatalf("Failed to load environment variables: %v", err)
-	}
-}
-
-func setupRouter() *mux.Router {
-	r := mux.NewRouter().StrictSlash(true)
-
-	// Middlewares
-	r.Use(middlewares.CorsMiddleware)
-	r.Use(middlewares.LoggingMiddleware)
-	r.Use(middlewares.ErrorHandlerMiddleware)
-	r.Use(middlewares.RecoverMiddleware)
-
-	// Not Found and Not Allow Handler
-	handler.NotFoundHandler(r)
-	handler.NotAllowHandler(r)
-
-	// Api V1
-	apiV1Router := r.PathPrefix("/api/v1").Subrouter()
-	handler.GoogleDriveRoutes(apiV1Router)
-	handler.HomeRoutes(apiV1Router)
-	handler.ProductRoutes(apiV1Router)
-
-	return r
-}
-
-func getServerConfig() *ServerConfig {
-	var wait time.Duration
-	flag.DurationVar(&wait, "graceful-timeout", time.Second*15,
-		"the duration for which the server gracefully wait for existing connections")
-	flag.Parse()
-
-	return &ServerConfig{
-		Host:          config.Env.Host,
-		Port:          config.Env.Port,
-		ReadTimeout:   time.Second * 15,
-		WriteTimeout:  time.Second * 15,
-		IdleTimeout:   time.Second * 60,
-		ShutdownDelay: wait,
-	}
-}
-
-func startServer(cfg *ServerConfig, handler http.Handler) *http.Server {
-	srv := &http.Server{
-		Addr:         fmt.Sprintf("%s:%s", cfg.Host, cfg.Port),
-		Handler:      handler,
-		ReadTimeout:  cfg.ReadTimeout,
-		WriteTimeout: cfg.WriteTimeout,
-		IdleTimeout:  cfg.IdleTimeout,
-	}
-
-	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("listen: %s\n", err)
-		}
-	}()
-
-	return srv
-}
-
-func waitForShutdown(ctx context.Context, srv *http.Server) {
-	// Handle system signals
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt)
-	select {
-	case <-ctx.Done():
-	case <-c:
-		log.Println("Shutdown signal received")
-	}
-
-	// Graceful shutdown
-	shutdownCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
-	defer cancel()
-
-	if err := srv.Shutdown(shutdownCtx); err != nil {
-		log.Printf("HTTP server Shutdown failed: %v", err)
-	}
-}
-
-func initGoogleDrive() {
-	googledrive.Init()
-}
-
-func initKafka(ctx context.Context) {
-	wg.Add(2)
-
-	go func() {
-		defer wg.Done()
-		if err := kafka.InitProducer(config.Env.KafkaBrokers); err != nil {
-			log.Fatalf("Failed to initialize Kafka producer: %v", err)
-		}
-	}()
-
-	go func() {
-		defer wg.Done()
-		if err := kafka.InitConsumer(config.Env.KafkaBrokers, config.Env.KafkaGroupID); err != nil {
-			log.Fatalf("Failed to initialize Kafka consumer: %v", err)
-		}
-	}()
-
-	// Wait for Kafka to be ready or context cancel
-	go func() {
-		<-ctx.Done()
-		log.Println("Kafka initialization canceled")
-	}()
-}
-
-func main() {
-	loadEnv()
-
-	cfg := getServerConfig()
-	router := setupRouter()
-
-	// Create context for graceful shutdown
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// Initialize Google Drive and Kafka
-	initGoogleDrive()
-	initKafka(ctx)
-
-	// Start HTTP server
-	srv := startServer(cfg, router)
-
-	// Wait for shutdown signal
-	waitForShutdown(ctx, srv)
-
-	// Wait for other services to close
-	wg.Wait()
-	log.Println("All services gracefully stopped")
-}
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"web-service/config"
+	"web-service/pkg/cdc"
+	"web-service/pkg/consumers"
+	"web-service/pkg/data"
+	"web-service/pkg/database"
+	"web-service/pkg/events"
+	googledrive "web-service/pkg/google-drive"
+	"web-service/pkg/handler"
+	"web-service/pkg/kafka"
+	"web-service/pkg/middlewares"
+	"web-service/pkg/outbox"
+	"web-service/pkg/storage"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ServerConfig struct {
+	Host          string
+	Port          string
+	ReadTimeout   time.Duration
+	WriteTimeout  time.Duration
+	IdleTimeout   time.Duration
+	ShutdownDelay time.Duration
+}
+
+// eventHistorySize is how many upload events the stream keeps for clients
+// resuming with Last-Event-ID.
+const eventHistorySize = 1000
+
+// topicSetupTimeout bounds creating and updating the Kafka topics at startup.
+const topicSetupTimeout = 30 * time.Second
+
+// kafkaTopics are the topics the service produces to or consumes from,
+// created at startup with the configured partitions and retention.
+var kafkaTopics = []string{
+	events.TopicFileUploaded,
+	kafka.DLQTopic(events.TopicFileUploaded),
+	events.TopicProductEvents,
+}
+
+var wg sync.WaitGroup
+
+// background tracks the workers that produce to Kafka and use MongoDB, so
+// both are only closed once the workers stopped.
+var background sync.WaitGroup
+
+var mongoClient *mongo.Client
+
+type repositories struct {
+	products    data.ProductRepository
+	apiKeys     data.APIKeyRepository
+	uploads     data.UploadRepository
+	outbox      data.OutboxRepository
+	deadLetters data.DeadLetterRepository
+	driveTokens googledrive.TokenStore
+}
+
+func loadEnv() {
+	if err := config.Load(); err != nil {
+		log.Fatalf("Failed to load environment variables: %v", err)
+	}
+}
+
+func setupRouter(repos *repositories, hub *events.Hub, kafkaAdmin kafka.Admin) *mux.Router {
+	r := mux.NewRouter().StrictSlash(true)
+
+	// Middlewares
+	r.Use(middlewares.CorsMiddleware)
+	r.Use(middlewares.LoggingMiddleware)
+	r.Use(middlewares.ErrorHandlerMiddleware)
+	r.Use(middlewares.APIKeyMiddleware(repos.apiKeys))
+	r.Use(middlewares.AuthMiddleware)
+
+	// Not Found and Not Allow Handler
+	handler.NotFoundHandler(r)
+	handler.NotAllowHandler(r)
+
+	var driveTokens *googledrive.TokenSources
+	if repos.driveTokens != nil {
+		driveTokens = googledrive.NewTokenSources(repos.driveTokens)
+	}
+
+	// Api V1
+	apiV1Router := r.PathPrefix("/api/v1").Subrouter()
+	handler.GoogleDriveRoutes(apiV1Router, driveTokens, repos.uploads, storage.Policy{
+		MaxSize: config.Env.GOOGLE_DRIVE_UPLOAD_MAX_SIZE,
+		Allowed: config.Env.GOOGLE_DRIVE_UPLOAD_ALLOWED_TYPES,
+		Denied:  config.Env.GOOGLE_DRIVE_UPLOAD_DENIED_TYPES,
+	})
+	handler.UploadEventRoutes(apiV1Router, hub)
+	handler.UploadRoutes(apiV1Router, newStorageProvider(driveTokens), config.Env.StorageDriver, repos.uploads, storage.Policy{
+		MaxSize: config.Env.UploadMaxSize,
+		Allowed: config.Env.UploadAllowedTypes,
+		Denied:  config.Env.UploadDeniedTypes,
+	})
+	handler.HomeRoutes(apiV1Router)
+	handler.ProductRoutes(apiV1Router, repos.products)
+	handler.APIKeyRoutes(apiV1Router, repos.apiKeys)
+	handler.DeadLetterRoutes(apiV1Router, repos.deadLetters)
+	handler.KafkaRoutes(apiV1Router, kafkaAdmin)
+
+	return r
+}
+
+func getServerConfig() *ServerConfig {
+	var wait time.Duration
+	flag.DurationVar(&wait, "graceful-timeout", time.Second*15,
+		"the duration for which the server gracefully wait for existing connections")
+	flag.Parse()
+
+	return &ServerConfig{
+		Host:          config.Env.Host,
+		Port:          config.Env.Port,
+		ReadTimeout:   time.Second * 15,
+		WriteTimeout:  time.Second * 15,
+		IdleTimeout:   time.Second * 60,
+		ShutdownDelay: wait,
+	}
+}
+
+func startServer(cfg *ServerConfig, handler http.Handler) *http.Server {
+	srv := &http.Server{
+		Addr:         fmt.Sprintf("%s:%s", cfg.Host, cfg.Port),
+		Handler:      handler,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("listen: %s\n", err)
+		}
+	}()
+
+	return srv
+}
+
+func waitForShutdown(ctx context.Context, srv *http.Server) {
+	// Handle system signals
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt)
+	select {
+	case <-ctx.Done():
+	case <-c:
+		log.Println("Shutdown signal received")
+	}
+
+	// Graceful shutdown
+	shutdownCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("HTTP server Shutdown failed: %v", err)
+	}
+}
+
+func initAuth() {
+	if err := middlewares.InitJWT(); err != nil {
+		log.Fatalf("Failed to initialize JWT authentication: %v", err)
+	}
+}
+
+func initDatabase(ctx context.Context) {
+	if config.Env.DBDriver == "memory" {
+		log.Println("Using in-memory storage")
+		return
+	}
+
+	client, err := database.MongoDBClient()
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	mongoClient = client
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-ctx.Done()
+		background.Wait()
+
+		if err := database.DisconnectMongoDB(mongoClient); err != nil {
+			log.Printf("Failed to disconnect MongoDB: %v", err)
+		}
+		log.Println("MongoDB disconnected")
+	}()
+}
+
+func newDriveTokenStore() googledrive.TokenStore {
+	if !googledrive.Enabled() {
+		return nil
+	}
+
+	if config.Env.GOOGLE_DRIVE_TOKEN_STORE == "mongodb" && mongoClient != nil {
+		return googledrive.NewMongoTokenStore(mongoClient.Database(config.Env.DBName))
+	}
+
+	store, err := googledrive.NewFileTokenStore(config.Env.GOOGLE_DRIVE_TOKEN_PATH)
+	if err != nil {
+		log.Fatalf("Failed to initialize Google Drive token store: %v", err)
+	}
+	return store
+}
+
+func newRepositories(ctx context.Context) *repositories {
+	if mongoClient == nil {
+		outbox := data.NewMemoryOutboxRepository()
+
+		return &repositories{
+			products:    data.NewMemoryProductRepository(data.DefaultProducts(), outbox),
+			apiKeys:     data.NewMemoryAPIKeyRepository(),
+			uploads:     data.NewMemoryUploadRepository(outbox),
+			outbox:      outbox,
+			deadLetters: data.NewMemoryDeadLetterRepository(),
+			driveTokens: newDriveTokenStore(),
+		}
+	}
+
+	db := mongoClient.Database(config.Env.DBName)
+
+	outbox, err := data.NewMongoOutboxRepository(ctx, db)
+	if err != nil {
+		log.Fatalf("Failed to initialize outbox repository: %v", err)
+	}
+
+	products, err := data.NewMongoProductRepository(ctx, db, outbox)
+	if err != nil {
+		log.Fatalf("Failed to initialize product repository: %v", err)
+	}
+
+	apiKeys, err := data.NewMongoAPIKeyRepository(ctx, db)
+	if err != nil {
+		log.Fatalf("Failed to initialize API key repository: %v", err)
+	}
+
+	uploads, err := data.NewMongoUploadRepository(ctx, db, outbox)
+	if err != nil {
+		log.Fatalf("Failed to initialize upload repository: %v", err)
+	}
+
+	deadLetters, err := data.NewMongoDeadLetterRepository(ctx, db)
+	if err != nil {
+		log.Fatalf("Failed to initialize dead letter repository: %v", err)
+	}
+
+	return &repositories{
+		products:    products,
+		apiKeys:     apiKeys,
+		uploads:     uploads,
+		outbox:      outbox,
+		deadLetters: deadLetters,
+		driveTokens: newDriveTokenStore(),
+	}
+}
+
+func newStorageProvider(driveTokens *googledrive.TokenSources) storage.Provider {
+	switch config.Env.StorageDriver {
+	case "local":
+		store, err := storage.NewLocalStorage(config.Env.LocalStoragePath)
+		if err != nil {
+			log.Fatalf("Failed to initialize local storage: %v", err)
+		}
+		return storage.Shared(store)
+	case "s3":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		store, err := storage.NewS3Storage(ctx, storage.S3Config{
+			Endpoint:  config.Env.S3Endpoint,
+			Region:    config.Env.S3Region,
+			Bucket:    config.Env.S3Bucket,
+			AccessKey: config.Env.S3AccessKey,
+			SecretKey: config.Env.S3SecretKey,
+			UseSSL:    config.Env.S3UseSSL,
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize S3 storage: %v", err)
+		}
+		return storage.Shared(store)
+	case "gdrive":
+		return storage.DriveProvider(driveTokens)
+	}
+
+	log.Fatalf("Unknown STORAGE_DRIVER %q", config.Env.StorageDriver)
+	return nil
+}
+
+func initGoogleDrive() {
+	err := googledrive.Init()
+	if errors.Is(err, googledrive.ErrNotConfigured) {
+		log.Println("GOOGLE_DRIVE_CREDENTIALS_PATH is not set, Google Drive routes are disabled")
+		return
+	}
+	if err != nil {
+		log.Fatalf("Failed to initialize Google Drive: %v", err)
+	}
+}
+
+func initKafka(ctx context.Context) kafka.Admin {
+	security := kafka.Security{
+		Protocol:      config.Env.KafkaSecurityProtocol,
+		SASLMechanism: config.Env.KafkaSASLMechanism,
+		SASLUsername:  config.Env.KafkaSASLUsername,
+		SASLPassword:  config.Env.KafkaSASLPassword,
+		CALocation:    config.Env.KafkaSSLCALocation,
+		CertLocation:  config.Env.KafkaSSLCertLocation,
+		KeyLocation:   config.Env.KafkaSSLKeyLocation,
+		KeyPassword:   config.Env.KafkaSSLKeyPassword,
+	}
+
+	if err := kafka.Init(config.Env.KafkaDriver, config.Env.KafkaBrokers, config.Env.KafkaGroupID, security); err != nil {
+		log.Fatalf("Failed to initialize Kafka: %v", err)
+	}
+
+	admin, err := kafka.NewAdmin(kafka.GetBroker())
+	if err != nil {
+		log.Fatalf("Failed to initialize Kafka admin: %v", err)
+	}
+	ensureTopics(ctx, admin)
+
+	if err := events.Init(config.Env.EventCodec); err != nil {
+		log.Fatalf("Failed to initialize event codec: %v", err)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-ctx.Done()
+		background.Wait()
+
+		if err := admin.Close(); err != nil {
+			log.Printf("Failed to close Kafka admin: %v", err)
+		}
+
+		// Close flushes the messages still waiting for delivery.
+		if err := kafka.Close(); err != nil {
+			log.Printf("Failed to close Kafka: %v", err)
+		}
+		log.Println("Kafka closed")
+	}()
+
+	return admin
+}
+
+func ensureTopics(ctx context.Context, admin kafka.Admin) {
+	names := append([]string(nil), kafkaTopics...)
+	for _, collection := range config.Env.CDCCollections {
+		names = append(names, cdc.Topic(config.Env.CDCTopicPrefix, collection))
+	}
+
+	topics := make([]kafka.TopicConfig, 0, len(names))
+	for _, name := range names {
+		topics = append(topics, kafka.TopicConfig{
+			Name:              name,
+			Partitions:        config.Env.KafkaTopicPartitions,
+			ReplicationFactor: config.Env.KafkaTopicReplicationFactor,
+			Retention:         config.Env.KafkaTopicRetention,
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, topicSetupTimeout)
+	defer cancel()
+
+	if err := admin.EnsureTopics(ctx, topics); err != nil {
+		log.Fatalf("Failed to set up Kafka topics: %v", err)
+	}
+}
+
+// startEventStream feeds upload events to the stream hub. Every instance
+// uses its own consumer group so each of them sees all events.
+func startEventStream(ctx context.Context, hub *events.Hub) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "local"
+	}
+
+	sub, err := kafka.GetBroker().Subscribe([]string{events.TopicFileUploaded}, kafka.SubscribeOptions{
+		GroupID:    config.Env.KafkaGroupID + "-stream-" + hostname,
+		FromLatest: true,
+	})
+	if err != nil {
+		log.Fatalf("Failed to subscribe to upload events: %v", err)
+	}
+
+	background.Add(1)
+	go func() {
+		defer background.Done()
+
+		hub.Run(ctx, sub)
+		if err := sub.Close(); err != nil {
+			log.Printf("Failed to close event stream subscription: %v", err)
+		}
+		log.Println("Event stream stopped")
+	}()
+}
+
+func startWorkers(ctx context.Context, repos *repositories) {
+	workers := kafka.NewWorkers(kafka.GetBroker(), config.Env.KafkaWorkerGroupID)
+	concurrency := config.Env.KafkaWorkerConcurrency
+
+	// Every handled topic has its dead letters archived for the admin API.
+	handle := func(topic string, handler kafka.HandlerFunc) {
+		workers.Handle(topic, kafka.HandlerOptions{Concurrency: concurrency[topic]}, handler)
+		workers.Handle(kafka.DLQTopic(topic), kafka.HandlerOptions{}, consumers.DeadLetters(repos.deadLetters))
+	}
+
+	handle(events.TopicFileUploaded, consumers.FileUploaded(repos.uploads))
+
+	if err := workers.Start(ctx, &background); err != nil {
+		log.Fatalf("Failed to start Kafka workers: %v", err)
+	}
+}
+
+// startChangeStreams publishes the changes of the configured collections to
+// Kafka. Change streams need MongoDB running as a replica set.
+func startChangeStreams(ctx context.Context) {
+	if len(config.Env.CDCCollections) == 0 {
+		return
+	}
+	if mongoClient == nil {
+		log.Println("Change data capture needs MongoDB, CDC_COLLECTIONS is ignored")
+		return
+	}
+
+	db := mongoClient.Database(config.Env.DBName)
+	tokens := cdc.NewMongoTokenStore(db)
+
+	for _, collection := range config.Env.CDCCollections {
+		stream := cdc.NewStream(db.Collection(collection), cdc.Topic(config.Env.CDCTopicPrefix, collection), tokens)
+
+		background.Add(1)
+		go func() {
+			defer background.Done()
+
+			stream.Run(ctx)
+			log.Printf("Change stream %s stopped", stream.Name())
+		}()
+	}
+}
+
+func startOutboxRelay(ctx context.Context, repo data.OutboxRepository) {
+	relay := outbox.NewRelay(repo)
+
+	background.Add(1)
+	go func() {
+		defer background.Done()
+
+		relay.Run(ctx)
+		log.Println("Outbox relay stopped")
+	}()
+}
+
+func main() {
+	loadEnv()
+
+	cfg := getServerConfig()
+
+	// Create context for graceful shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Initialize auth, database, Google Drive and Kafka
+	initAuth()
+	initDatabase(ctx)
+	initGoogleDrive()
+	kafkaAdmin := initKafka(ctx)
+
+	repos := newRepositories(ctx)
+	hub := events.NewHub(eventHistorySize)
+	router := setupRouter(repos, hub, kafkaAdmin)
+
+	// Relay queued events and collection changes to Kafka and consume them in
+	// the background
+	startOutboxRelay(ctx, repos.outbox)
+	startEventStream(ctx, hub)
+	startWorkers(ctx, repos)
+	startChangeStreams(ctx)
+
+	// Start HTTP server
+	srv := startServer(cfg, router)
+	srv.RegisterOnShutdown(hub.Close)
+
+	// Wait for shutdown signal
+	waitForShutdown(ctx, srv)
+	cancel()
+
+	// Wait for other services to close
+	wg.Wait()
+	log.Println("All services gracefully stopped")
+}
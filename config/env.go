@@ -1,87 +1,369 @@
-package config
-
-import (
-	"fmt"
-	"os"
-
-	"github.com/joho/godotenv"
-)
-
-var (
-	Env *Config
-)
-
-type Config struct {
-	// Server configs
-	Host        string
-	Port        string
-	Environment string
-
-	// Database configs
-	DBHost     string
-	DBPort     string
-	DBUser     string
-	DBPassword string
-	DBName     string
-	DBPoolSize string
-
-	// CORS configs
-	CORSOrigins []string
-	CORSMethods []string
-	CORSHeaders []string
-	CORSMaxAge  int
-
-	//Google Drive configs
-	GOOGLE_DRIVE_CREDENTIALS_PATH string
-	GOOGLE_DRIVE_TOKEN_PATH       string
-	GOOGLE_DRIVE_REDIRECT_URL     string
-
-	//Kafka configs
-	KafkaBrokers string
-	KafkaGroupID string
-}
-
-func Load() error {
-	if err := godotenv.Load(".env"); err != nil {
-		return fmt.Errorf("error loading .env file: %w", err)
-	}
-
-	Env = &Config{
-		// Server configs
-		Host:        getEnvOrDefault("HOST", "localhost"),
-		Port:        getEnvOrDefault("PORT", "8080"),
-		Environment: getEnvOrDefault("GO_ENV", "DEV"),
-
-		// Database configs
-		DBHost:     getEnvOrDefault("DB_HOST", "localhost"),
-		DBPort:     getEnvOrDefault("DB_PORT", "27017"),
-		DBUser:     getEnvOrDefault("DB_USER", "mongodb"),
-		DBPassword: getEnvOrDefault("DB_PASSWORD", "mongodb"),
-		DBName:     getEnvOrDefault("DB_NAME", "mongodb"),
-		DBPoolSize: getEnvOrDefault("DB_POOL_SIZE", "100"),
-
-		// CORS configs
-		CORSOrigins: []string{getEnvOrDefault("CORS_ORIGINS", "http://localhost:3000")},
-		CORSMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		CORSHeaders: []string{"Content-Type", "Authorization"},
-		CORSMaxAge:  3600,
-
-		//Google Drive configs
-		GOOGLE_DRIVE_CREDENTIALS_PATH: getEnvOrDefault("GOOGLE_DRIVE_CREDENTIALS_PATH", ""),
-		GOOGLE_DRIVE_TOKEN_PATH:       getEnvOrDefault("GOOGLE_DRIVE_TOKEN_PATH", ""),
-		GOOGLE_DRIVE_REDIRECT_URL:     getEnvOrDefault("GOOGLE_DRIVE_REDIRECT_URL", ""),
-
-		//Kafka configs
-		KafkaBrokers: getEnvOrDefault("KAFKA_BROKERS", "localhost:9092"),
-		KafkaGroupID: getEnvOrDefault("KAFKA_GROUP_ID", "my-group"),
-	}
-
-	return nil
-}
-
-func getEnvOrDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+var (
+	Env *Config
+)
+
+type Config struct {
+	// Server configs
+	Host        string
+	Port        string
+	Environment string
+
+	// Database configs
+	DBDriver   string
+	DBHost     string
+	DBPort     string
+	DBUser     string
+	DBPassword string
+	DBName     string
+	DBPoolSize string
+
+	// CORS configs
+	CORSOrigins []string
+	CORSMethods []string
+	CORSHeaders []string
+	CORSMaxAge  int
+
+	// Auth configs
+	JWTSecret        string
+	JWTPublicKeyPath string
+	JWTIssuer        string
+	JWTAudience      string
+
+	//Google Drive configs
+	GOOGLE_DRIVE_CREDENTIALS_PATH string
+	GOOGLE_DRIVE_TOKEN_PATH       string
+	GOOGLE_DRIVE_TOKEN_STORE      string
+	GOOGLE_DRIVE_REDIRECT_URL     string
+
+	GOOGLE_DRIVE_UPLOAD_MAX_SIZE      int64
+	GOOGLE_DRIVE_UPLOAD_ALLOWED_TYPES []string
+	GOOGLE_DRIVE_UPLOAD_DENIED_TYPES  []string
+
+	// Upload configs
+	UploadMaxSize      int64
+	UploadAllowedTypes []string
+	UploadDeniedTypes  []string
+
+	// Storage configs
+	StorageDriver    string
+	LocalStoragePath string
+	S3Endpoint       string
+	S3Region         string
+	S3Bucket         string
+	S3AccessKey      string
+	S3SecretKey      string
+	S3UseSSL         bool
+
+	//Kafka configs
+	KafkaDriver  string
+	KafkaBrokers string
+	KafkaGroupID string
+	EventCodec   string
+
+	// Kafka security configs
+	KafkaSecurityProtocol string
+	KafkaSASLMechanism    string
+	KafkaSASLUsername     string
+	KafkaSASLPassword     string
+	KafkaSSLCALocation    string
+	KafkaSSLCertLocation  string
+	KafkaSSLKeyLocation   string
+	KafkaSSLKeyPassword   string
+
+	// Kafka topic configs, applied to the topics the service declares
+	KafkaTopicPartitions        int
+	KafkaTopicReplicationFactor int
+	KafkaTopicRetention         time.Duration
+
+	// Background consumer configs
+	KafkaWorkerGroupID     string
+	KafkaWorkerConcurrency map[string]int
+
+	// Change data capture configs
+	CDCCollections []string
+	CDCTopicPrefix string
+}
+
+func Load() error {
+	if err := godotenv.Load(".env"); err != nil {
+		return fmt.Errorf("error loading .env file: %w", err)
+	}
+
+	uploadMaxSize, err := getEnvInt64("UPLOAD_MAX_SIZE", 100<<20)
+	if err != nil {
+		return err
+	}
+
+	driveUploadMaxSize, err := getEnvInt64("GOOGLE_DRIVE_UPLOAD_MAX_SIZE", uploadMaxSize)
+	if err != nil {
+		return err
+	}
+
+	workerConcurrency, err := getEnvIntMap("KAFKA_WORKER_CONCURRENCY")
+	if err != nil {
+		return err
+	}
+
+	topicPartitions, err := getEnvInt("KAFKA_TOPIC_PARTITIONS", 1)
+	if err != nil {
+		return err
+	}
+
+	topicReplicationFactor, err := getEnvInt("KAFKA_TOPIC_REPLICATION_FACTOR", 1)
+	if err != nil {
+		return err
+	}
+
+	topicRetention, err := getEnvDuration("KAFKA_TOPIC_RETENTION", 0)
+	if err != nil {
+		return err
+	}
+
+	kafkaGroupID := getEnvOrDefault("KAFKA_GROUP_ID", "my-group")
+
+	uploadAllowedTypes := getEnvList("UPLOAD_ALLOWED_TYPES", nil)
+	uploadDeniedTypes := getEnvList("UPLOAD_DENIED_TYPES", nil)
+
+	Env = &Config{
+		// Server configs
+		Host:        getEnvOrDefault("HOST", "localhost"),
+		Port:        getEnvOrDefault("PORT", "8080"),
+		Environment: getEnvOrDefault("GO_ENV", "DEV"),
+
+		// Database configs
+		DBDriver:   getEnvOrDefault("DB_DRIVER", "mongodb"),
+		DBHost:     getEnvOrDefault("DB_HOST", "localhost"),
+		DBPort:     getEnvOrDefault("DB_PORT", "27017"),
+		DBUser:     getEnvOrDefault("DB_USER", "mongodb"),
+		DBPassword: getEnvOrDefault("DB_PASSWORD", "mongodb"),
+		DBName:     getEnvOrDefault("DB_NAME", "mongodb"),
+		DBPoolSize: getEnvOrDefault("DB_POOL_SIZE", "100"),
+
+		// CORS configs
+		CORSOrigins: []string{getEnvOrDefault("CORS_ORIGINS", "http://localhost:3000")},
+		CORSMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		CORSHeaders: []string{"Content-Type", "Authorization", "X-API-Key"},
+		CORSMaxAge:  3600,
+
+		// Auth configs
+		JWTSecret:        getEnvOrDefault("JWT_SECRET", ""),
+		JWTPublicKeyPath: getEnvOrDefault("JWT_PUBLIC_KEY_PATH", ""),
+		JWTIssuer:        getEnvOrDefault("JWT_ISSUER", ""),
+		JWTAudience:      getEnvOrDefault("JWT_AUDIENCE", ""),
+
+		//Google Drive configs
+		GOOGLE_DRIVE_CREDENTIALS_PATH: getEnvOrDefault("GOOGLE_DRIVE_CREDENTIALS_PATH", ""),
+		GOOGLE_DRIVE_TOKEN_PATH:       getEnvOrDefault("GOOGLE_DRIVE_TOKEN_PATH", ""),
+		GOOGLE_DRIVE_TOKEN_STORE:      getEnvOrDefault("GOOGLE_DRIVE_TOKEN_STORE", "file"),
+		GOOGLE_DRIVE_REDIRECT_URL:     getEnvOrDefault("GOOGLE_DRIVE_REDIRECT_URL", ""),
+
+		GOOGLE_DRIVE_UPLOAD_MAX_SIZE:      driveUploadMaxSize,
+		GOOGLE_DRIVE_UPLOAD_ALLOWED_TYPES: getEnvList("GOOGLE_DRIVE_UPLOAD_ALLOWED_TYPES", uploadAllowedTypes),
+		GOOGLE_DRIVE_UPLOAD_DENIED_TYPES:  getEnvList("GOOGLE_DRIVE_UPLOAD_DENIED_TYPES", uploadDeniedTypes),
+
+		// Upload configs
+		UploadMaxSize:      uploadMaxSize,
+		UploadAllowedTypes: uploadAllowedTypes,
+		UploadDeniedTypes:  uploadDeniedTypes,
+
+		// Storage configs
+		StorageDriver:    getEnvOrDefault("STORAGE_DRIVER", "gdrive"),
+		LocalStoragePath: getEnvOrDefault("LOCAL_STORAGE_PATH", "./uploads"),
+		S3Endpoint:       getEnvOrDefault("S3_ENDPOINT", "localhost:9000"),
+		S3Region:         getEnvOrDefault("S3_REGION", "us-east-1"),
+		S3Bucket:         getEnvOrDefault("S3_BUCKET", "uploads"),
+		S3AccessKey:      getEnvOrDefault("S3_ACCESS_KEY", ""),
+		S3SecretKey:      getEnvOrDefault("S3_SECRET_KEY", ""),
+		S3UseSSL:         getEnvOrDefault("S3_USE_SSL", "false") == "true",
+
+		//Kafka configs
+		KafkaDriver:  getEnvOrDefault("KAFKA_DRIVER", "confluent"),
+		KafkaBrokers: getEnvOrDefault("KAFKA_BROKERS", "localhost:9092"),
+		KafkaGroupID: kafkaGroupID,
+		EventCodec:   getEnvOrDefault("EVENT_CODEC", "json"),
+
+		// Kafka security configs
+		KafkaSecurityProtocol: strings.ToUpper(getEnvOrDefault("KAFKA_SECURITY_PROTOCOL", "PLAINTEXT")),
+		KafkaSASLMechanism:    strings.ToUpper(getEnvOrDefault("KAFKA_SASL_MECHANISM", "")),
+		KafkaSASLUsername:     getEnvOrDefault("KAFKA_SASL_USERNAME", ""),
+		KafkaSASLPassword:     getEnvOrDefault("KAFKA_SASL_PASSWORD", ""),
+		KafkaSSLCALocation:    getEnvOrDefault("KAFKA_SSL_CA_LOCATION", ""),
+		KafkaSSLCertLocation:  getEnvOrDefault("KAFKA_SSL_CERT_LOCATION", ""),
+		KafkaSSLKeyLocation:   getEnvOrDefault("KAFKA_SSL_KEY_LOCATION", ""),
+		KafkaSSLKeyPassword:   getEnvOrDefault("KAFKA_SSL_KEY_PASSWORD", ""),
+
+		// Kafka topic configs
+		KafkaTopicPartitions:        topicPartitions,
+		KafkaTopicReplicationFactor: topicReplicationFactor,
+		KafkaTopicRetention:         topicRetention,
+
+		// Background consumer configs
+		KafkaWorkerGroupID:     getEnvOrDefault("KAFKA_WORKER_GROUP_ID", kafkaGroupID+"-workers"),
+		KafkaWorkerConcurrency: workerConcurrency,
+
+		// Change data capture configs
+		CDCCollections: getEnvList("CDC_COLLECTIONS", nil),
+		CDCTopicPrefix: getEnvOrDefault("CDC_TOPIC_PREFIX", "cdc"),
+	}
+
+	return validateKafkaSecurity(Env)
+}
+
+// validateKafkaSecurity checks that the Kafka security settings fit
+// together, so a misconfiguration fails at startup instead of surfacing as
+// connection errors later. All problems are reported at once.
+func validateKafkaSecurity(cfg *Config) error {
+	var errs []error
+
+	sasl, tls := false, false
+	switch cfg.KafkaSecurityProtocol {
+	case "PLAINTEXT":
+	case "SSL":
+		tls = true
+	case "SASL_PLAINTEXT":
+		sasl = true
+	case "SASL_SSL":
+		sasl, tls = true, true
+	default:
+		errs = append(errs, fmt.Errorf("invalid KAFKA_SECURITY_PROTOCOL %q, expected PLAINTEXT, SSL, SASL_PLAINTEXT or SASL_SSL", cfg.KafkaSecurityProtocol))
+	}
+
+	if sasl {
+		switch cfg.KafkaSASLMechanism {
+		case "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512":
+		case "":
+			errs = append(errs, fmt.Errorf("KAFKA_SASL_MECHANISM is required with KAFKA_SECURITY_PROTOCOL %s, set it to PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512", cfg.KafkaSecurityProtocol))
+		default:
+			errs = append(errs, fmt.Errorf("invalid KAFKA_SASL_MECHANISM %q, expected PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512", cfg.KafkaSASLMechanism))
+		}
+		if cfg.KafkaSASLUsername == "" || cfg.KafkaSASLPassword == "" {
+			errs = append(errs, fmt.Errorf("KAFKA_SASL_USERNAME and KAFKA_SASL_PASSWORD are required with KAFKA_SECURITY_PROTOCOL %s", cfg.KafkaSecurityProtocol))
+		}
+	} else if cfg.KafkaSASLMechanism != "" || cfg.KafkaSASLUsername != "" || cfg.KafkaSASLPassword != "" {
+		errs = append(errs, fmt.Errorf("KAFKA_SASL_* settings need KAFKA_SECURITY_PROTOCOL SASL_SSL or SASL_PLAINTEXT, it is %s", cfg.KafkaSecurityProtocol))
+	}
+
+	files := []struct{ key, path string }{
+		{"KAFKA_SSL_CA_LOCATION", cfg.KafkaSSLCALocation},
+		{"KAFKA_SSL_CERT_LOCATION", cfg.KafkaSSLCertLocation},
+		{"KAFKA_SSL_KEY_LOCATION", cfg.KafkaSSLKeyLocation},
+	}
+
+	if tls {
+		for _, file := range files {
+			if file.path == "" {
+				continue
+			}
+			if _, err := os.Stat(file.path); err != nil {
+				errs = append(errs, fmt.Errorf("unable to read %s: %w", file.key, err))
+			}
+		}
+
+		if (cfg.KafkaSSLCertLocation == "") != (cfg.KafkaSSLKeyLocation == "") {
+			errs = append(errs, errors.New("KAFKA_SSL_CERT_LOCATION and KAFKA_SSL_KEY_LOCATION must be set together for a client certificate"))
+		}
+		if cfg.KafkaSSLKeyPassword != "" && cfg.KafkaSSLKeyLocation == "" {
+			errs = append(errs, errors.New("KAFKA_SSL_KEY_PASSWORD is set without KAFKA_SSL_KEY_LOCATION"))
+		}
+	} else if cfg.KafkaSSLCALocation != "" || cfg.KafkaSSLCertLocation != "" || cfg.KafkaSSLKeyLocation != "" || cfg.KafkaSSLKeyPassword != "" {
+		errs = append(errs, fmt.Errorf("KAFKA_SSL_* settings need KAFKA_SECURITY_PROTOCOL SSL or SASL_SSL, it is %s", cfg.KafkaSecurityProtocol))
+	}
+
+	return errors.Join(errs...)
+}
+
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt64(key string, defaultValue int64) (int64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", key, value, err)
+	}
+	return n, nil
+}
+
+func getEnvInt(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", key, value, err)
+	}
+	return n, nil
+}
+
+// getEnvDuration reads a duration such as "168h".
+func getEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", key, value, err)
+	}
+	return d, nil
+}
+
+// getEnvList reads a comma separated list, ignoring empty entries.
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getEnvIntMap reads a comma separated list of key=number pairs, such as
+// "file_uploaded=2,products=1".
+func getEnvIntMap(key string) (map[string]int, error) {
+	m := make(map[string]int)
+
+	for _, item := range getEnvList(key, nil) {
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid %s entry %q, expected name=number", key, item)
+		}
+
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s entry %q: %w", key, item, err)
+		}
+		m[strings.TrimSpace(name)] = n
+	}
+	return m, nil
+}
@@ -0,0 +1,233 @@
+package cdc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+	"web-service/pkg/events"
+	"web-service/pkg/kafka"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	baseBackoff = time.Second
+	maxBackoff  = time.Minute
+	// produceTimeout bounds the wait for a delivery report.
+	produceTimeout = 10 * time.Second
+	saveTimeout    = 5 * time.Second
+
+	// errHistoryLost is returned by the server when a resume token is
+	// older than the oldest oplog entry.
+	errHistoryLost = 286
+)
+
+// Topic is the topic the changes of collection are published to.
+func Topic(prefix, collection string) string {
+	return prefix + "." + collection
+}
+
+// Stream publishes the inserts, updates, replaces and deletes of a MongoDB
+// collection to a Kafka topic, keyed by document so the changes of a
+// document stay in order.
+//
+// The resume token of a change is saved once the broker acknowledged it,
+// so changes are published at least once: a crash in between publishes the
+// change again on restart. Its envelope ID is derived from the resume token
+// for consumers to drop such duplicates. Change streams need a replica set,
+// and only one instance should run the stream of a collection.
+type Stream struct {
+	collection *mongo.Collection
+	topic      string
+	tokens     TokenStore
+	produce    func(ctx context.Context, msg *kafka.Message) error
+}
+
+type changeEvent struct {
+	OperationType string              `bson:"operationType"`
+	ClusterTime   primitive.Timestamp `bson:"clusterTime"`
+	Namespace     struct {
+		DB         string `bson:"db"`
+		Collection string `bson:"coll"`
+	} `bson:"ns"`
+	DocumentKey       bson.Raw      `bson:"documentKey"`
+	FullDocument      bson.RawValue `bson:"fullDocument"`
+	UpdateDescription struct {
+		UpdatedFields bson.Raw `bson:"updatedFields"`
+		RemovedFields []string `bson:"removedFields"`
+	} `bson:"updateDescription"`
+}
+
+func NewStream(collection *mongo.Collection, topic string, tokens TokenStore) *Stream {
+	return &Stream{
+		collection: collection,
+		topic:      topic,
+		tokens:     tokens,
+		produce:    kafka.ProduceMessageSync,
+	}
+}
+
+// Name identifies the stream in the token store.
+func (s *Stream) Name() string {
+	return s.collection.Database().Name() + "." + s.collection.Name()
+}
+
+// Run publishes changes until ctx is done, reopening the change stream with
+// a backoff whenever it fails.
+func (s *Stream) Run(ctx context.Context) {
+	failures := 0
+
+	for {
+		published, err := s.watch(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if published > 0 {
+			failures = 0
+		}
+		wait := backoff(failures)
+		failures++
+
+		var serverErr mongo.ServerError
+		if errors.As(err, &serverErr) && serverErr.HasErrorCode(errHistoryLost) {
+			// Nothing can bring the missed changes back, starting over at
+			// least keeps the stream going.
+			log.Printf("Change stream %s can't resume, its token left the oplog and changes since were missed: %v", s.Name(), err)
+			if err := s.tokens.Delete(ctx, s.Name()); err != nil {
+				log.Printf("Failed to delete resume token of %s: %v", s.Name(), err)
+			}
+		} else {
+			log.Printf("Change stream %s closed, reopening in %s: %v", s.Name(), wait, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// watch opens the change stream after the saved resume token and publishes
+// changes until it fails, returning how many were published.
+func (s *Stream) watch(ctx context.Context) (int, error) {
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+
+	token, err := s.tokens.Get(ctx, s.Name())
+	switch {
+	case err == nil:
+		// Unlike resumeAfter, startAfter also resumes after an invalidate
+		// event such as a dropped collection.
+		opts.SetStartAfter(token)
+	case !errors.Is(err, ErrTokenNotFound):
+		return 0, err
+	}
+
+	changes, err := s.collection.Watch(ctx, mongo.Pipeline{}, opts)
+	if err != nil {
+		return 0, err
+	}
+	defer changes.Close(context.WithoutCancel(ctx))
+
+	published := 0
+	for changes.Next(ctx) {
+		var change changeEvent
+		if err := changes.Decode(&change); err != nil {
+			return published, err
+		}
+
+		token := changes.ResumeToken()
+		if err := s.publish(ctx, change, token); err != nil {
+			return published, err
+		}
+
+		// Keep the token even when shutdown interrupted the stream, the
+		// change is already published.
+		saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), saveTimeout)
+		err := s.tokens.Save(saveCtx, s.Name(), token)
+		cancel()
+		if err != nil {
+			return published, err
+		}
+		published++
+	}
+
+	return published, changes.Err()
+}
+
+// publish produces change to the stream's topic. Other operations such as
+// drop or invalidate are skipped, only their token is saved.
+func (s *Stream) publish(ctx context.Context, change changeEvent, token bson.Raw) error {
+	switch change.OperationType {
+	case events.OperationInsert, events.OperationUpdate, events.OperationReplace, events.OperationDelete:
+	default:
+		return nil
+	}
+
+	documentKey, err := extendedJSON(change.DocumentKey)
+	if err != nil {
+		return err
+	}
+
+	payload := events.DocumentChanged{
+		Operation:   change.OperationType,
+		Database:    change.Namespace.DB,
+		Collection:  change.Namespace.Collection,
+		DocumentKey: documentKey,
+		ClusterTime: time.Unix(int64(change.ClusterTime.T), 0).UTC(),
+	}
+
+	// The full document of an update is looked up after the fact, and is
+	// missing when the document was deleted in the meantime.
+	if document, ok := change.FullDocument.DocumentOK(); ok {
+		if payload.Document, err = extendedJSON(document); err != nil {
+			return err
+		}
+	}
+
+	if change.OperationType == events.OperationUpdate {
+		if payload.UpdatedFields, err = extendedJSON(change.UpdateDescription.UpdatedFields); err != nil {
+			return err
+		}
+		payload.RemovedFields = change.UpdateDescription.RemovedFields
+	}
+
+	e, err := events.NewDocumentChanged(payload)
+	if err != nil {
+		return err
+	}
+	e.ID = uuid.NewSHA1(uuid.NameSpaceOID, token).String()
+
+	msg, err := events.Encode(s.topic, e)
+	if err != nil {
+		return err
+	}
+	msg.Key = documentKey
+
+	ctx, cancel := context.WithTimeout(ctx, produceTimeout)
+	defer cancel()
+
+	return s.produce(ctx, msg)
+}
+
+func extendedJSON(doc bson.Raw) (json.RawMessage, error) {
+	if doc == nil {
+		return nil, nil
+	}
+	return bson.MarshalExtJSON(doc, false, false)
+}
+
+func backoff(failures int) time.Duration {
+	d := baseBackoff
+	for i := 0; i < failures && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
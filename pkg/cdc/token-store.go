@@ -0,0 +1,62 @@
+package cdc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const tokenCollection = "cdc_resume_tokens"
+
+var ErrTokenNotFound = errors.New("cdc: resume token not found")
+
+// TokenStore keeps the resume token of each change stream, so a restarted
+// stream continues after the last change it published.
+type TokenStore interface {
+	Get(ctx context.Context, stream string) (bson.Raw, error)
+	Save(ctx context.Context, stream string, token bson.Raw) error
+	Delete(ctx context.Context, stream string) error
+}
+
+type MongoTokenStore struct {
+	collection *mongo.Collection
+}
+
+type tokenDocument struct {
+	Stream    string    `bson:"_id"`
+	Token     bson.Raw  `bson:"token"`
+	UpdatedAt time.Time `bson:"updatedAt"`
+}
+
+func NewMongoTokenStore(db *mongo.Database) *MongoTokenStore {
+	return &MongoTokenStore{collection: db.Collection(tokenCollection)}
+}
+
+func (s *MongoTokenStore) Get(ctx context.Context, stream string) (bson.Raw, error) {
+	var doc tokenDocument
+
+	err := s.collection.FindOne(ctx, bson.D{{Key: "_id", Value: stream}}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrTokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return doc.Token, nil
+}
+
+func (s *MongoTokenStore) Save(ctx context.Context, stream string, token bson.Raw) error {
+	doc := tokenDocument{Stream: stream, Token: token, UpdatedAt: time.Now().UTC()}
+
+	_, err := s.collection.ReplaceOne(ctx, bson.D{{Key: "_id", Value: stream}}, doc, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (s *MongoTokenStore) Delete(ctx context.Context, stream string) error {
+	_, err := s.collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: stream}})
+	return err
+}
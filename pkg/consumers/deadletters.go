@@ -0,0 +1,48 @@
+package consumers
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+	"web-service/pkg/data"
+	"web-service/pkg/kafka"
+)
+
+// DeadLetters archives messages of dead-letter topics so they can be
+// inspected and replayed through the admin API.
+func DeadLetters(repo data.DeadLetterRepository) kafka.HandlerFunc {
+	return func(ctx context.Context, msg *kafka.Message) error {
+		dl := data.DeadLetter{
+			ID:          data.DeadLetterID(msg.Topic, msg.Partition, msg.Offset),
+			Topic:       msg.Topic,
+			SourceTopic: msg.Headers[kafka.HeaderDLQTopic],
+			Key:         msg.Key,
+			Value:       msg.Value,
+			Error:       msg.Headers[kafka.HeaderDLQError],
+			ReceivedAt:  time.Now().UTC(),
+		}
+		if dl.SourceTopic == "" {
+			dl.SourceTopic = kafka.DLQSourceTopic(msg.Topic)
+		}
+
+		dl.Attempts, _ = strconv.Atoi(msg.Headers[kafka.HeaderDLQAttempts])
+		dl.FailedAt, _ = time.Parse(time.RFC3339Nano, msg.Headers[kafka.HeaderDLQFailedAt])
+		if dl.FailedAt.IsZero() {
+			dl.FailedAt = msg.Timestamp
+		}
+
+		// Keep only the headers of the original message.
+		for k, v := range msg.Headers {
+			if strings.HasPrefix(k, "dlq-") && k != kafka.HeaderDLQReplayCount {
+				continue
+			}
+			if dl.Headers == nil {
+				dl.Headers = make(map[string]string)
+			}
+			dl.Headers[k] = v
+		}
+
+		return repo.Save(ctx, dl)
+	}
+}
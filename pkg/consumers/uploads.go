@@ -0,0 +1,37 @@
+package consumers
+
+import (
+	"context"
+	"errors"
+	"web-service/pkg/data"
+	"web-service/pkg/events"
+	"web-service/pkg/kafka"
+)
+
+// FileUploaded marks catalogued uploads as processed once their event has
+// made it through Kafka.
+func FileUploaded(uploads data.UploadRepository) kafka.HandlerFunc {
+	return func(ctx context.Context, msg *kafka.Message) error {
+		e, err := events.Decode(msg)
+		if err != nil {
+			return kafka.Permanent(err)
+		}
+		if e.Type != events.TypeFileUploaded {
+			return nil
+		}
+
+		var file events.FileUploaded
+		if err := e.DecodeData(&file); err != nil {
+			return kafka.Permanent(err)
+		}
+		if file.UploadID == "" {
+			return nil
+		}
+
+		err = uploads.UpdateStatus(ctx, file.UploadID, data.UploadStatusProcessed)
+		if errors.Is(err, data.ErrUploadNotFound) {
+			return kafka.Permanent(err)
+		}
+		return err
+	}
+}
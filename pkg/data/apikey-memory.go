@@ -0,0 +1,85 @@
+package data
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type MemoryAPIKeyRepository struct {
+	mu   sync.RWMutex
+	keys map[string]APIKey
+}
+
+func NewMemoryAPIKeyRepository() *MemoryAPIKeyRepository {
+	return &MemoryAPIKeyRepository{keys: make(map[string]APIKey)}
+}
+
+func (r *MemoryAPIKeyRepository) Create(ctx context.Context, key APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.keys[key.ID] = key
+	return nil
+}
+
+func (r *MemoryAPIKeyRepository) List(ctx context.Context) ([]APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]APIKey, 0, len(r.keys))
+	for _, key := range r.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+func (r *MemoryAPIKeyRepository) GetByID(ctx context.Context, id string) (APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, ok := r.keys[id]
+	if !ok {
+		return APIKey{}, ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+func (r *MemoryAPIKeyRepository) GetByHash(ctx context.Context, hash string) (APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.keys {
+		if key.Hash == hash {
+			return key, nil
+		}
+	}
+	return APIKey{}, ErrAPIKeyNotFound
+}
+
+func (r *MemoryAPIKeyRepository) Revoke(ctx context.Context, id string, at time.Time) error {
+	return r.update(id, func(key *APIKey) {
+		if key.RevokedAt == nil {
+			key.RevokedAt = &at
+		}
+	})
+}
+
+func (r *MemoryAPIKeyRepository) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	return r.update(id, func(key *APIKey) { key.LastUsedAt = &at })
+}
+
+func (r *MemoryAPIKeyRepository) update(id string, fn func(key *APIKey)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.keys[id]
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+	fn(&key)
+	r.keys[id] = key
+	return nil
+}
@@ -0,0 +1,89 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const apiKeyCollection = "api_keys"
+
+type MongoAPIKeyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoAPIKeyRepository(ctx context.Context, db *mongo.Database) (*MongoAPIKeyRepository, error) {
+	r := &MongoAPIKeyRepository{collection: db.Collection(apiKeyCollection)}
+
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "hash", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *MongoAPIKeyRepository) Create(ctx context.Context, key APIKey) error {
+	_, err := r.collection.InsertOne(ctx, key)
+	return err
+}
+
+func (r *MongoAPIKeyRepository) List(ctx context.Context) ([]APIKey, error) {
+	cursor, err := r.collection.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []APIKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *MongoAPIKeyRepository) GetByID(ctx context.Context, id string) (APIKey, error) {
+	return r.findOne(ctx, bson.D{{Key: "_id", Value: id}})
+}
+
+func (r *MongoAPIKeyRepository) GetByHash(ctx context.Context, hash string) (APIKey, error) {
+	return r.findOne(ctx, bson.D{{Key: "hash", Value: hash}})
+}
+
+func (r *MongoAPIKeyRepository) findOne(ctx context.Context, filter bson.D) (APIKey, error) {
+	var key APIKey
+
+	err := r.collection.FindOne(ctx, filter).Decode(&key)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return APIKey{}, ErrAPIKeyNotFound
+	}
+	return key, err
+}
+
+func (r *MongoAPIKeyRepository) Revoke(ctx context.Context, id string, at time.Time) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: id}},
+		bson.D{{Key: "$min", Value: bson.D{{Key: "revokedAt", Value: at}}}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (r *MongoAPIKeyRepository) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: id}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "lastUsedAt", Value: at}}}},
+	)
+	return err
+}
@@ -0,0 +1,96 @@
+package data
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type MemoryDeadLetterRepository struct {
+	mu          sync.RWMutex
+	deadLetters map[string]DeadLetter
+}
+
+func NewMemoryDeadLetterRepository() *MemoryDeadLetterRepository {
+	return &MemoryDeadLetterRepository{deadLetters: make(map[string]DeadLetter)}
+}
+
+func (r *MemoryDeadLetterRepository) Save(ctx context.Context, dl DeadLetter) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.deadLetters[dl.ID]; !ok {
+		r.deadLetters[dl.ID] = dl
+	}
+	return nil
+}
+
+func (r *MemoryDeadLetterRepository) GetByID(ctx context.Context, id string) (DeadLetter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	dl, ok := r.deadLetters[id]
+	if !ok {
+		return DeadLetter{}, ErrDeadLetterNotFound
+	}
+	return dl, nil
+}
+
+func (r *MemoryDeadLetterRepository) List(ctx context.Context, query DeadLetterQuery) (DeadLetterPage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matched := []DeadLetter{}
+	for _, dl := range r.deadLetters {
+		if query.SourceTopic != "" && dl.SourceTopic != query.SourceTopic {
+			continue
+		}
+		if query.Replayed != nil && (dl.ReplayedAt != nil) != *query.Replayed {
+			continue
+		}
+		matched = append(matched, dl)
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		if !matched[i].ReceivedAt.Equal(matched[j].ReceivedAt) {
+			return matched[i].ReceivedAt.After(matched[j].ReceivedAt)
+		}
+		return matched[i].ID < matched[j].ID
+	})
+
+	total := int64(len(matched))
+	start := min(query.Page.Offset, len(matched))
+	end := len(matched)
+	if query.Page.Limit > 0 {
+		end = min(start+query.Page.Limit, len(matched))
+	}
+
+	return DeadLetterPage{Items: matched[start:end], Total: total}, nil
+}
+
+func (r *MemoryDeadLetterRepository) MarkReplayed(ctx context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	dl, ok := r.deadLetters[id]
+	if !ok {
+		return ErrDeadLetterNotFound
+	}
+
+	dl.ReplayedAt = &at
+	dl.ReplayCount++
+	r.deadLetters[id] = dl
+	return nil
+}
+
+func (r *MemoryDeadLetterRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.deadLetters[id]; !ok {
+		return ErrDeadLetterNotFound
+	}
+	delete(r.deadLetters, id)
+	return nil
+}
@@ -0,0 +1,110 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const deadLetterCollection = "dead_letters"
+
+type MongoDeadLetterRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoDeadLetterRepository(ctx context.Context, db *mongo.Database) (*MongoDeadLetterRepository, error) {
+	r := &MongoDeadLetterRepository{collection: db.Collection(deadLetterCollection)}
+
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "sourceTopic", Value: 1}, {Key: "receivedAt", Value: -1}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *MongoDeadLetterRepository) Save(ctx context.Context, dl DeadLetter) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: dl.ID}},
+		bson.D{{Key: "$setOnInsert", Value: dl}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *MongoDeadLetterRepository) GetByID(ctx context.Context, id string) (DeadLetter, error) {
+	var dl DeadLetter
+
+	err := r.collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&dl)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return DeadLetter{}, ErrDeadLetterNotFound
+	}
+	return dl, err
+}
+
+func (r *MongoDeadLetterRepository) List(ctx context.Context, query DeadLetterQuery) (DeadLetterPage, error) {
+	filter := bson.D{}
+	if query.SourceTopic != "" {
+		filter = append(filter, bson.E{Key: "sourceTopic", Value: query.SourceTopic})
+	}
+	if query.Replayed != nil {
+		filter = append(filter, bson.E{Key: "replayedAt", Value: bson.D{{Key: "$exists", Value: *query.Replayed}}})
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return DeadLetterPage{}, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "receivedAt", Value: -1}, {Key: "_id", Value: 1}}).
+		SetSkip(int64(query.Page.Offset))
+	if query.Page.Limit > 0 {
+		opts.SetLimit(int64(query.Page.Limit))
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return DeadLetterPage{}, err
+	}
+
+	deadLetters := []DeadLetter{}
+	if err := cursor.All(ctx, &deadLetters); err != nil {
+		return DeadLetterPage{}, err
+	}
+	return DeadLetterPage{Items: deadLetters, Total: total}, nil
+}
+
+func (r *MongoDeadLetterRepository) MarkReplayed(ctx context.Context, id string, at time.Time) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: id}},
+		bson.D{
+			{Key: "$set", Value: bson.D{{Key: "replayedAt", Value: at}}},
+			{Key: "$inc", Value: bson.D{{Key: "replayCount", Value: 1}}},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrDeadLetterNotFound
+	}
+	return nil
+}
+
+func (r *MongoDeadLetterRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: id}})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrDeadLetterNotFound
+	}
+	return nil
+}
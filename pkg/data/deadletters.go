@@ -0,0 +1,56 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"web-service/pkg/utils"
+)
+
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// DeadLetter is a message archived from a dead-letter topic. Its ID is
+// derived from the DLQ position, so archiving the same message twice keeps
+// one copy.
+type DeadLetter struct {
+	ID          string            `json:"id" bson:"_id"`
+	Topic       string            `json:"topic" bson:"topic"`
+	SourceTopic string            `json:"sourceTopic" bson:"sourceTopic"`
+	Key         []byte            `json:"key,omitempty" bson:"key,omitempty"`
+	Value       []byte            `json:"value" bson:"value"`
+	Headers     map[string]string `json:"headers,omitempty" bson:"headers,omitempty"`
+	Error       string            `json:"error" bson:"error"`
+	Attempts    int               `json:"attempts" bson:"attempts"`
+
+	FailedAt    time.Time  `json:"failedAt" bson:"failedAt"`
+	ReceivedAt  time.Time  `json:"receivedAt" bson:"receivedAt"`
+	ReplayedAt  *time.Time `json:"replayedAt,omitempty" bson:"replayedAt,omitempty"`
+	ReplayCount int        `json:"replayCount" bson:"replayCount"`
+}
+
+func DeadLetterID(topic string, partition int32, offset int64) string {
+	return fmt.Sprintf("%s-%d-%d", topic, partition, offset)
+}
+
+type DeadLetterQuery struct {
+	SourceTopic string
+	// Replayed filters on whether the message was replayed when set.
+	Replayed *bool
+	Page     utils.Page
+}
+
+type DeadLetterPage struct {
+	Items []DeadLetter
+	Total int64
+}
+
+type DeadLetterRepository interface {
+	// Save stores the dead letter unless one with the same ID exists.
+	Save(ctx context.Context, dl DeadLetter) error
+	GetByID(ctx context.Context, id string) (DeadLetter, error)
+	// List returns the newest dead letters first.
+	List(ctx context.Context, query DeadLetterQuery) (DeadLetterPage, error)
+	MarkReplayed(ctx context.Context, id string, at time.Time) error
+	Delete(ctx context.Context, id string) error
+}
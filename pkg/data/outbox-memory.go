@@ -0,0 +1,87 @@
+package data
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type MemoryOutboxRepository struct {
+	mu       sync.Mutex
+	messages []*OutboxMessage
+}
+
+func NewMemoryOutboxRepository() *MemoryOutboxRepository {
+	return &MemoryOutboxRepository{}
+}
+
+func (r *MemoryOutboxRepository) add(messages []OutboxMessage) {
+	if len(messages) == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range messages {
+		message := messages[i]
+		r.messages = append(r.messages, &message)
+	}
+}
+
+func (r *MemoryOutboxRepository) Claim(ctx context.Context, now time.Time, lease time.Duration) (OutboxMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Messages are kept in the order they were written.
+	blocked := make(map[string]bool)
+	for _, message := range r.messages {
+		if message.Status != OutboxPending {
+			continue
+		}
+
+		key := message.Topic + "\x00" + string(message.Key)
+		ordered := len(message.Key) > 0
+		if ordered && blocked[key] {
+			continue
+		}
+
+		if !message.NextAttemptAt.After(now) {
+			message.NextAttemptAt = now.Add(lease)
+			return *message, nil
+		}
+		if ordered {
+			blocked[key] = true
+		}
+	}
+	return OutboxMessage{}, ErrOutboxEmpty
+}
+
+func (r *MemoryOutboxRepository) MarkSent(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Sent messages are of no further use in memory.
+	for i, message := range r.messages {
+		if message.ID == id {
+			r.messages = append(r.messages[:i], r.messages[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *MemoryOutboxRepository) MarkFailed(ctx context.Context, id string, reason string, next time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, message := range r.messages {
+		if message.ID == id {
+			message.Attempts++
+			message.LastError = reason
+			message.NextAttemptAt = next
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryOutboxClaimKeepsKeyOrder(t *testing.T) {
+	ctx := context.Background()
+
+	outbox := NewMemoryOutboxRepository()
+	outbox.add([]OutboxMessage{
+		NewOutboxMessage("products", []byte("1"), []byte("created"), nil),
+		NewOutboxMessage("products", []byte("1"), []byte("deleted"), nil),
+		NewOutboxMessage("products", []byte("2"), []byte("created"), nil),
+	})
+	now := time.Now().UTC()
+
+	created := claim(t, outbox, now)
+	if string(created.Value) != "created" || string(created.Key) != "1" {
+		t.Fatalf("claimed %s of %s, want created of 1", created.Value, created.Key)
+	}
+	if err := outbox.MarkFailed(ctx, created.ID, "broker down", now.Add(time.Minute)); err != nil {
+		t.Fatalf("MarkFailed: %v", err)
+	}
+
+	// The deletion of 1 waits for its creation, other keys go ahead.
+	if other := claim(t, outbox, now); string(other.Key) != "2" {
+		t.Fatalf("claimed %s of %s while the creation of 1 is retried", other.Value, other.Key)
+	}
+	if _, err := outbox.Claim(ctx, now, time.Minute); !errors.Is(err, ErrOutboxEmpty) {
+		t.Fatalf("Claim: err = %v, want %v", err, ErrOutboxEmpty)
+	}
+
+	later := now.Add(time.Minute)
+	if retried := claim(t, outbox, later); retried.ID != created.ID {
+		t.Fatalf("claimed %s of %s, want the retried creation of 1", retried.Value, retried.Key)
+	}
+	if err := outbox.MarkSent(ctx, created.ID); err != nil {
+		t.Fatalf("MarkSent: %v", err)
+	}
+	if deleted := claim(t, outbox, later); string(deleted.Value) != "deleted" {
+		t.Fatalf("claimed %s of %s, want deleted of 1", deleted.Value, deleted.Key)
+	}
+}
+
+func claim(t *testing.T, outbox *MemoryOutboxRepository, now time.Time) OutboxMessage {
+	t.Helper()
+
+	message, err := outbox.Claim(context.Background(), now, time.Minute)
+	if err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+	return message
+}
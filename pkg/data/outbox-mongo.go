@@ -0,0 +1,154 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	outboxCollection = "outbox"
+
+	// outboxRetention is how long sent messages are kept for inspection.
+	outboxRetention = 7 * 24 * time.Hour
+)
+
+type MongoOutboxRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoOutboxRepository(ctx context.Context, db *mongo.Database) (*MongoOutboxRepository, error) {
+	r := &MongoOutboxRepository{collection: db.Collection(outboxCollection)}
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}, {Key: "createdAt", Value: 1}}},
+		{Keys: bson.D{
+			{Key: "topic", Value: 1},
+			{Key: "key", Value: 1},
+			{Key: "status", Value: 1},
+			{Key: "createdAt", Value: 1},
+			{Key: "_id", Value: 1},
+		}},
+		{
+			Keys:    bson.D{{Key: "sentAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(outboxRetention.Seconds())),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *MongoOutboxRepository) insert(ctx context.Context, messages []OutboxMessage) error {
+	docs := make([]interface{}, len(messages))
+	for i, message := range messages {
+		docs[i] = message
+	}
+
+	_, err := r.collection.InsertMany(ctx, docs)
+	return err
+}
+
+func (r *MongoOutboxRepository) Claim(ctx context.Context, now time.Time, lease time.Duration) (OutboxMessage, error) {
+	due := bson.D{
+		{Key: "status", Value: OutboxPending},
+		{Key: "nextAttemptAt", Value: bson.D{{Key: "$lte", Value: now}}},
+	}
+
+	cursor, err := r.collection.Find(ctx, due, options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}).
+		SetProjection(bson.D{{Key: "value", Value: 0}}))
+	if err != nil {
+		return OutboxMessage{}, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var candidate OutboxMessage
+		if err := cursor.Decode(&candidate); err != nil {
+			return OutboxMessage{}, err
+		}
+
+		waiting, err := r.hasOlderPending(ctx, candidate)
+		if err != nil {
+			return OutboxMessage{}, err
+		}
+		if waiting {
+			continue
+		}
+
+		var message OutboxMessage
+		err = r.collection.FindOneAndUpdate(ctx,
+			append(bson.D{{Key: "_id", Value: candidate.ID}}, due...),
+			bson.D{{Key: "$set", Value: bson.D{{Key: "nextAttemptAt", Value: now.Add(lease)}}}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&message)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// Another relay claimed it first.
+			continue
+		}
+		return message, err
+	}
+	if err := cursor.Err(); err != nil {
+		return OutboxMessage{}, err
+	}
+
+	return OutboxMessage{}, ErrOutboxEmpty
+}
+
+// hasOlderPending reports whether a message written before message with the
+// same topic and key is still pending, due or not. Messages without a key
+// have no order to keep.
+func (r *MongoOutboxRepository) hasOlderPending(ctx context.Context, message OutboxMessage) (bool, error) {
+	if len(message.Key) == 0 {
+		return false, nil
+	}
+
+	err := r.collection.FindOne(ctx, bson.D{
+		{Key: "topic", Value: message.Topic},
+		{Key: "key", Value: message.Key},
+		{Key: "status", Value: OutboxPending},
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: "createdAt", Value: bson.D{{Key: "$lt", Value: message.CreatedAt}}}},
+			bson.D{
+				{Key: "createdAt", Value: message.CreatedAt},
+				{Key: "_id", Value: bson.D{{Key: "$lt", Value: message.ID}}},
+			},
+		}},
+	}, options.FindOne().SetProjection(bson.D{{Key: "_id", Value: 1}})).Err()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *MongoOutboxRepository) MarkSent(ctx context.Context, id string) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: id}},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "status", Value: OutboxSent},
+			{Key: "sentAt", Value: time.Now().UTC()},
+		}}},
+	)
+	return err
+}
+
+func (r *MongoOutboxRepository) MarkFailed(ctx context.Context, id string, reason string, next time.Time) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: id}},
+		bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: "lastError", Value: reason},
+				{Key: "nextAttemptAt", Value: next},
+			}},
+			{Key: "$inc", Value: bson.D{{Key: "attempts", Value: 1}}},
+		},
+	)
+	return err
+}
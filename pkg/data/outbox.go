@@ -0,0 +1,60 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	OutboxPending = "pending"
+	OutboxSent    = "sent"
+)
+
+var ErrOutboxEmpty = errors.New("no outbox message is due")
+
+// OutboxMessage is a Kafka message waiting to be relayed. It is written in
+// the same operation as the change it announces.
+type OutboxMessage struct {
+	ID        string            `json:"id" bson:"_id"`
+	Topic     string            `json:"topic" bson:"topic"`
+	Key       []byte            `json:"key,omitempty" bson:"key,omitempty"`
+	Value     []byte            `json:"value" bson:"value"`
+	Headers   map[string]string `json:"headers,omitempty" bson:"headers,omitempty"`
+	Status    string            `json:"status" bson:"status"`
+	Attempts  int               `json:"attempts" bson:"attempts"`
+	LastError string            `json:"lastError,omitempty" bson:"lastError,omitempty"`
+
+	CreatedAt     time.Time  `json:"createdAt" bson:"createdAt"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt" bson:"nextAttemptAt"`
+	SentAt        *time.Time `json:"sentAt,omitempty" bson:"sentAt,omitempty"`
+}
+
+func NewOutboxMessage(topic string, key, value []byte, headers map[string]string) OutboxMessage {
+	now := time.Now().UTC()
+
+	return OutboxMessage{
+		ID:            uuid.NewString(),
+		Topic:         topic,
+		Key:           key,
+		Value:         value,
+		Headers:       headers,
+		Status:        OutboxPending,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}
+}
+
+type OutboxRepository interface {
+	// Claim leases the oldest pending message that is due, hiding it from
+	// other relays until the lease ends. A message waits while an older one
+	// with the same topic and key is pending, so the messages of a key are
+	// relayed in order even when one of them is retried. It returns
+	// ErrOutboxEmpty when nothing is due.
+	Claim(ctx context.Context, now time.Time, lease time.Duration) (OutboxMessage, error)
+	MarkSent(ctx context.Context, id string) error
+	// MarkFailed records a failed attempt and schedules the next one.
+	MarkFailed(ctx context.Context, id string, reason string, next time.Time) error
+}
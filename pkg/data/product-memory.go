@@ -0,0 +1,148 @@
+package data
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"web-service/pkg/utils"
+)
+
+type MemoryProductRepository struct {
+	mu       sync.RWMutex
+	products []ProductData
+	outbox   *MemoryOutboxRepository
+}
+
+func NewMemoryProductRepository(seed []ProductData, outbox *MemoryOutboxRepository) *MemoryProductRepository {
+	return &MemoryProductRepository{products: append([]ProductData(nil), seed...), outbox: outbox}
+}
+
+func (r *MemoryProductRepository) List(ctx context.Context, query ProductQuery) (ProductPage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	name := strings.ToLower(query.Name)
+	description := strings.ToLower(query.Description)
+
+	matched := []ProductData{}
+	for _, product := range r.products {
+		if !strings.Contains(strings.ToLower(product.Name), name) ||
+			!strings.Contains(strings.ToLower(product.Description), description) {
+			continue
+		}
+		matched = append(matched, product)
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return lessProduct(matched[i], matched[j], query.Sort)
+	})
+
+	total := int64(len(matched))
+	start := min(query.Page.Offset, len(matched))
+	end := len(matched)
+	if query.Page.Limit > 0 {
+		end = min(start+query.Page.Limit, len(matched))
+	}
+
+	return ProductPage{Items: matched[start:end], Total: total}, nil
+}
+
+func lessProduct(a, b ProductData, fields []utils.SortField) bool {
+	for _, field := range fields {
+		var cmp int
+		switch field.Field {
+		case "id":
+			cmp = a.ID - b.ID
+		case "name":
+			cmp = strings.Compare(a.Name, b.Name)
+		case "description":
+			cmp = strings.Compare(a.Description, b.Description)
+		}
+
+		if cmp != 0 {
+			return (cmp < 0) != field.Desc
+		}
+	}
+	return a.ID < b.ID
+}
+
+func (r *MemoryProductRepository) GetByID(ctx context.Context, id int) (ProductData, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, product := range r.products {
+		if product.ID == id {
+			return product, nil
+		}
+	}
+	return ProductData{}, ErrProductNotFound
+}
+
+func (r *MemoryProductRepository) Create(ctx context.Context, product ProductData, outbox ProductOutbox) (ProductData, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	maxID := 0
+	for _, existing := range r.products {
+		if existing.ID > maxID {
+			maxID = existing.ID
+		}
+	}
+
+	product.ID = maxID + 1
+
+	messages, err := announce(outbox, nil, &product)
+	if err != nil {
+		return ProductData{}, err
+	}
+
+	r.products = append(r.products, product)
+	r.outbox.add(messages)
+	return product, nil
+}
+
+func (r *MemoryProductRepository) Update(ctx context.Context, product ProductData, outbox ProductOutbox) (ProductData, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, existing := range r.products {
+		if existing.ID == product.ID {
+			messages, err := announce(outbox, &existing, &product)
+			if err != nil {
+				return ProductData{}, err
+			}
+
+			r.products[i] = product
+			r.outbox.add(messages)
+			return existing, nil
+		}
+	}
+	return ProductData{}, ErrProductNotFound
+}
+
+func (r *MemoryProductRepository) Delete(ctx context.Context, id int, outbox ProductOutbox) (ProductData, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, product := range r.products {
+		if product.ID == id {
+			messages, err := announce(outbox, &product, nil)
+			if err != nil {
+				return ProductData{}, err
+			}
+
+			r.products = append(r.products[:i], r.products[i+1:]...)
+			r.outbox.add(messages)
+			return product, nil
+		}
+	}
+	return ProductData{}, ErrProductNotFound
+}
+
+func announce(outbox ProductOutbox, before, after *ProductData) ([]OutboxMessage, error) {
+	if outbox == nil {
+		return nil, nil
+	}
+	return outbox(before, after)
+}
@@ -0,0 +1,237 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"web-service/pkg/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	productCollection = "products"
+	counterCollection = "counters"
+)
+
+type MongoProductRepository struct {
+	collection *mongo.Collection
+	counters   *mongo.Collection
+	outbox     *MongoOutboxRepository
+}
+
+func NewMongoProductRepository(ctx context.Context, db *mongo.Database, outbox *MongoOutboxRepository) (*MongoProductRepository, error) {
+	r := &MongoProductRepository{
+		collection: db.Collection(productCollection),
+		counters:   db.Collection(counterCollection),
+		outbox:     outbox,
+	}
+
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.syncCounter(ctx); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// syncCounter moves the ID counter past the highest stored ID, products
+// created before IDs were assigned by the server or restored from a backup
+// may be ahead of it.
+func (r *MongoProductRepository) syncCounter(ctx context.Context) error {
+	var last ProductData
+
+	opts := options.FindOne().SetSort(bson.D{{Key: "id", Value: -1}})
+	err := r.collection.FindOne(ctx, bson.D{}, opts).Decode(&last)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = r.counters.UpdateOne(
+		ctx,
+		bson.D{{Key: "_id", Value: productCollection}},
+		bson.D{{Key: "$max", Value: bson.D{{Key: "seq", Value: last.ID}}}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *MongoProductRepository) List(ctx context.Context, query ProductQuery) (ProductPage, error) {
+	filter := bson.D{}
+	if query.Name != "" {
+		filter = append(filter, bson.E{Key: "name", Value: containsRegex(query.Name)})
+	}
+	if query.Description != "" {
+		filter = append(filter, bson.E{Key: "description", Value: containsRegex(query.Description)})
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return ProductPage{}, err
+	}
+
+	opts := options.Find().
+		SetSort(sortDocument(query.Sort)).
+		SetSkip(int64(query.Page.Offset))
+	if query.Page.Limit > 0 {
+		opts.SetLimit(int64(query.Page.Limit))
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return ProductPage{}, err
+	}
+
+	products := []ProductData{}
+	if err := cursor.All(ctx, &products); err != nil {
+		return ProductPage{}, err
+	}
+	return ProductPage{Items: products, Total: total}, nil
+}
+
+func (r *MongoProductRepository) GetByID(ctx context.Context, id int) (ProductData, error) {
+	var product ProductData
+
+	err := r.collection.FindOne(ctx, bson.D{{Key: "id", Value: id}}).Decode(&product)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ProductData{}, ErrProductNotFound
+	}
+	return product, err
+}
+
+func (r *MongoProductRepository) Create(ctx context.Context, product ProductData, outbox ProductOutbox) (ProductData, error) {
+	id, err := r.nextID(ctx)
+	if err != nil {
+		return ProductData{}, err
+	}
+	product.ID = id
+
+	err = r.write(ctx, outbox, func(ctx context.Context) (*ProductData, *ProductData, error) {
+		_, err := r.collection.InsertOne(ctx, product)
+		return nil, &product, err
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		// Catch the counter up so that a retry gets a free ID.
+		if err := r.syncCounter(ctx); err != nil {
+			return ProductData{}, err
+		}
+		return ProductData{}, ErrProductExists
+	}
+	if err != nil {
+		return ProductData{}, err
+	}
+	return product, nil
+}
+
+func (r *MongoProductRepository) nextID(ctx context.Context) (int, error) {
+	var counter struct {
+		Seq int `bson:"seq"`
+	}
+
+	err := r.counters.FindOneAndUpdate(
+		ctx,
+		bson.D{{Key: "_id", Value: productCollection}},
+		bson.D{{Key: "$inc", Value: bson.D{{Key: "seq", Value: 1}}}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+
+	return counter.Seq, err
+}
+
+func (r *MongoProductRepository) Update(ctx context.Context, product ProductData, outbox ProductOutbox) (ProductData, error) {
+	var previous ProductData
+
+	err := r.write(ctx, outbox, func(ctx context.Context) (*ProductData, *ProductData, error) {
+		err := r.collection.FindOneAndReplace(ctx, bson.D{{Key: "id", Value: product.ID}}, product).Decode(&previous)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil, ErrProductNotFound
+		}
+		return &previous, &product, err
+	})
+	if err != nil {
+		return ProductData{}, err
+	}
+	return previous, nil
+}
+
+func (r *MongoProductRepository) Delete(ctx context.Context, id int, outbox ProductOutbox) (ProductData, error) {
+	var deleted ProductData
+
+	err := r.write(ctx, outbox, func(ctx context.Context) (*ProductData, *ProductData, error) {
+		err := r.collection.FindOneAndDelete(ctx, bson.D{{Key: "id", Value: id}}).Decode(&deleted)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil, ErrProductNotFound
+		}
+		return &deleted, nil, err
+	})
+	if err != nil {
+		return ProductData{}, err
+	}
+	return deleted, nil
+}
+
+// write applies change, in a transaction together with the messages of
+// outbox when there is one. Transactions need MongoDB to run as a replica
+// set (see docker-compose-rs.yaml).
+func (r *MongoProductRepository) write(ctx context.Context, outbox ProductOutbox, change func(ctx context.Context) (before, after *ProductData, err error)) error {
+	if outbox == nil {
+		_, _, err := change(ctx)
+		return err
+	}
+
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		before, after, err := change(sc)
+		if err != nil {
+			return nil, err
+		}
+
+		messages, err := outbox(before, after)
+		if err != nil || len(messages) == 0 {
+			return nil, err
+		}
+		return nil, r.outbox.insert(sc, messages)
+	})
+	return err
+}
+
+func containsRegex(value string) primitive.Regex {
+	return primitive.Regex{Pattern: regexp.QuoteMeta(value), Options: "i"}
+}
+
+func sortDocument(fields []utils.SortField) bson.D {
+	doc := bson.D{}
+	hasID := false
+
+	for _, field := range fields {
+		direction := 1
+		if field.Desc {
+			direction = -1
+		}
+		doc = append(doc, bson.E{Key: field.Field, Value: direction})
+		hasID = hasID || field.Field == "id"
+	}
+
+	if !hasID {
+		doc = append(doc, bson.E{Key: "id", Value: 1})
+	}
+	return doc
+}
@@ -1,13 +1,65 @@
-package data
-
-type ProductData struct {
-	ID          int    `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-}
-
-var ListProduct = []ProductData{
-	{ID: 1, Name: "Product 1", Description: "This is product 1"},
-	{ID: 2, Name: "Product 2", Description: "This is product 2"},
-	{ID: 3, Name: "Product 3", Description: "This is product 3"},
-}
+package data
+
+import (
+	"context"
+	"errors"
+	"web-service/pkg/utils"
+)
+
+var (
+	ErrProductNotFound = errors.New("product not found")
+	ErrProductExists   = errors.New("product already exists")
+)
+
+type ProductData struct {
+	ID          int    `json:"id" bson:"id" validate:"min=0"`
+	Name        string `json:"name" bson:"name" validate:"required,max=100"`
+	Description string `json:"description" bson:"description" validate:"max=2000"`
+}
+
+var ProductSortFields = []string{"id", "name", "description"}
+
+// ProductQuery filters products by case-insensitive substrings of name and
+// description. Results are ordered by Sort, then by id.
+type ProductQuery struct {
+	Name        string
+	Description string
+	Sort        []utils.SortField
+	Page        utils.Page
+}
+
+type ProductPage struct {
+	Items []ProductData
+	Total int64
+}
+
+// ProductOutbox builds the outbox messages announcing a product change from
+// the product before and after it. before is nil for a created product and
+// after for a deleted one. It runs as part of the write, so an error leaves
+// the product unchanged.
+type ProductOutbox func(before, after *ProductData) ([]OutboxMessage, error)
+
+// ProductRepository stores products. Its writes store the messages of outbox
+// together with the change, so that either all of them are written or none.
+// outbox may be nil when the change is not announced.
+type ProductRepository interface {
+	List(ctx context.Context, query ProductQuery) (ProductPage, error)
+	GetByID(ctx context.Context, id int) (ProductData, error)
+	// Create stores a new product under the next free ID, product.ID is
+	// ignored. It returns ErrProductExists when the ID turns out to be
+	// taken already.
+	Create(ctx context.Context, product ProductData, outbox ProductOutbox) (ProductData, error)
+	// Update replaces the product with the same ID and returns the product
+	// as it was before.
+	Update(ctx context.Context, product ProductData, outbox ProductOutbox) (ProductData, error)
+	// Delete removes a product and returns it.
+	Delete(ctx context.Context, id int, outbox ProductOutbox) (ProductData, error)
+}
+
+func DefaultProducts() []ProductData {
+	return []ProductData{
+		{ID: 1, Name: "Product 1", Description: "This is product 1"},
+		{ID: 2, Name: "Product 2", Description: "This is product 2"},
+		{ID: 3, Name: "Product 3", Description: "This is product 3"},
+	}
+}
@@ -0,0 +1,116 @@
+package data
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"web-service/pkg/utils"
+)
+
+type MemoryUploadRepository struct {
+	mu      sync.RWMutex
+	uploads []Upload
+	outbox  *MemoryOutboxRepository
+}
+
+func NewMemoryUploadRepository(outbox *MemoryOutboxRepository) *MemoryUploadRepository {
+	return &MemoryUploadRepository{outbox: outbox}
+}
+
+func (r *MemoryUploadRepository) Create(ctx context.Context, upload Upload, outbox ...OutboxMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.uploads = append(r.uploads, upload)
+	r.outbox.add(outbox)
+	return nil
+}
+
+func (r *MemoryUploadRepository) GetByID(ctx context.Context, id string) (Upload, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, upload := range r.uploads {
+		if upload.ID == id {
+			return upload, nil
+		}
+	}
+	return Upload{}, ErrUploadNotFound
+}
+
+func (r *MemoryUploadRepository) List(ctx context.Context, query UploadQuery) (UploadPage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	name := strings.ToLower(query.Name)
+
+	matched := []Upload{}
+	for _, upload := range r.uploads {
+		switch {
+		case query.Uploader != "" && upload.Uploader != query.Uploader,
+			query.MimeType != "" && upload.MimeType != query.MimeType,
+			query.Status != "" && upload.Status != query.Status,
+			!strings.Contains(strings.ToLower(upload.Name), name),
+			query.From != nil && upload.CreatedAt.Before(*query.From),
+			query.To != nil && upload.CreatedAt.After(*query.To):
+			continue
+		}
+		matched = append(matched, upload)
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return lessUpload(matched[i], matched[j], query.Sort)
+	})
+
+	total := int64(len(matched))
+	start := min(query.Page.Offset, len(matched))
+	end := len(matched)
+	if query.Page.Limit > 0 {
+		end = min(start+query.Page.Limit, len(matched))
+	}
+
+	return UploadPage{Items: matched[start:end], Total: total}, nil
+}
+
+func lessUpload(a, b Upload, fields []utils.SortField) bool {
+	for _, field := range fields {
+		var cmp int
+		switch field.Field {
+		case "createdAt":
+			cmp = a.CreatedAt.Compare(b.CreatedAt)
+		case "name":
+			cmp = strings.Compare(a.Name, b.Name)
+		case "size":
+			cmp = compareInt64(a.Size, b.Size)
+		}
+
+		if cmp != 0 {
+			return (cmp < 0) != field.Desc
+		}
+	}
+	return a.CreatedAt.After(b.CreatedAt)
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func (r *MemoryUploadRepository) UpdateStatus(ctx context.Context, id, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.uploads {
+		if r.uploads[i].ID == id {
+			r.uploads[i].Status = status
+			return nil
+		}
+	}
+	return ErrUploadNotFound
+}
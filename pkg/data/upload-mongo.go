@@ -0,0 +1,152 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"web-service/pkg/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const uploadCollection = "uploads"
+
+type MongoUploadRepository struct {
+	collection *mongo.Collection
+	outbox     *MongoOutboxRepository
+}
+
+func NewMongoUploadRepository(ctx context.Context, db *mongo.Database, outbox *MongoOutboxRepository) (*MongoUploadRepository, error) {
+	r := &MongoUploadRepository{collection: db.Collection(uploadCollection), outbox: outbox}
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "uploader", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "sha256", Value: 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Create runs in a transaction when there are outbox messages, which needs
+// MongoDB to run as a replica set (see docker-compose-rs.yaml).
+func (r *MongoUploadRepository) Create(ctx context.Context, upload Upload, outbox ...OutboxMessage) error {
+	if len(outbox) == 0 {
+		_, err := r.collection.InsertOne(ctx, upload)
+		return err
+	}
+
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		if _, err := r.collection.InsertOne(sc, upload); err != nil {
+			return nil, err
+		}
+		return nil, r.outbox.insert(sc, outbox)
+	})
+	return err
+}
+
+func (r *MongoUploadRepository) GetByID(ctx context.Context, id string) (Upload, error) {
+	var upload Upload
+
+	err := r.collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&upload)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return Upload{}, ErrUploadNotFound
+	}
+	return upload, err
+}
+
+func (r *MongoUploadRepository) List(ctx context.Context, query UploadQuery) (UploadPage, error) {
+	filter := bson.D{}
+	if query.Uploader != "" {
+		filter = append(filter, bson.E{Key: "uploader", Value: query.Uploader})
+	}
+	if query.Name != "" {
+		filter = append(filter, bson.E{Key: "name", Value: containsRegex(query.Name)})
+	}
+	if query.MimeType != "" {
+		filter = append(filter, bson.E{Key: "mimeType", Value: query.MimeType})
+	}
+	if query.Status != "" {
+		filter = append(filter, bson.E{Key: "status", Value: query.Status})
+	}
+
+	createdAt := bson.D{}
+	if query.From != nil {
+		createdAt = append(createdAt, bson.E{Key: "$gte", Value: *query.From})
+	}
+	if query.To != nil {
+		createdAt = append(createdAt, bson.E{Key: "$lte", Value: *query.To})
+	}
+	if len(createdAt) > 0 {
+		filter = append(filter, bson.E{Key: "createdAt", Value: createdAt})
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return UploadPage{}, err
+	}
+
+	opts := options.Find().SetSort(uploadSortDocument(query.Sort)).SetSkip(int64(query.Page.Offset))
+	if query.Page.Limit > 0 {
+		opts.SetLimit(int64(query.Page.Limit))
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return UploadPage{}, err
+	}
+
+	uploads := []Upload{}
+	if err := cursor.All(ctx, &uploads); err != nil {
+		return UploadPage{}, err
+	}
+	return UploadPage{Items: uploads, Total: total}, nil
+}
+
+// uploadSortDocument orders by fields, then newest first and by _id so pages
+// stay stable. A tiebreaker the client already sorts by is not repeated.
+func uploadSortDocument(fields []utils.SortField) bson.D {
+	doc := bson.D{}
+	seen := map[string]bool{}
+
+	for _, field := range fields {
+		direction := 1
+		if field.Desc {
+			direction = -1
+		}
+		doc = append(doc, bson.E{Key: field.Field, Value: direction})
+		seen[field.Field] = true
+	}
+
+	tiebreakers := bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: 1}}
+	for _, tiebreaker := range tiebreakers {
+		if !seen[tiebreaker.Key] {
+			doc = append(doc, tiebreaker)
+		}
+	}
+	return doc
+}
+
+func (r *MongoUploadRepository) UpdateStatus(ctx context.Context, id, status string) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: id}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "status", Value: status}}}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrUploadNotFound
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+	"web-service/pkg/utils"
+)
+
+const (
+	UploadStatusUploaded = "uploaded"
+	// UploadStatusProcessed means the upload event went through Kafka and
+	// was handled by the background workers.
+	UploadStatusProcessed = "processed"
+	UploadStatusFailed    = "failed"
+)
+
+var ErrUploadNotFound = errors.New("upload not found")
+
+var UploadSortFields = []string{"createdAt", "name", "size"}
+
+type Upload struct {
+	ID       string `json:"id" bson:"_id"`
+	FileID   string `json:"fileId,omitempty" bson:"fileId,omitempty"`
+	Storage  string `json:"storage" bson:"storage"`
+	Name     string `json:"name" bson:"name"`
+	Size     int64  `json:"size" bson:"size"`
+	MimeType string `json:"mimeType" bson:"mimeType"`
+	SHA256   string `json:"sha256,omitempty" bson:"sha256,omitempty"`
+	Uploader string `json:"uploader" bson:"uploader"`
+	Status   string `json:"status" bson:"status"`
+	Error    string `json:"error,omitempty" bson:"error,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+}
+
+// UploadQuery matches Name as a case-insensitive substring and the other
+// fields exactly. From and To bound CreatedAt when set.
+type UploadQuery struct {
+	Uploader string
+	Name     string
+	MimeType string
+	Status   string
+	From     *time.Time
+	To       *time.Time
+	Sort     []utils.SortField
+	Page     utils.Page
+}
+
+type UploadPage struct {
+	Items []Upload
+	Total int64
+}
+
+type UploadRepository interface {
+	// Create stores the upload together with the outbox messages announcing
+	// it, so that either all of them are written or none.
+	Create(ctx context.Context, upload Upload, outbox ...OutboxMessage) error
+	GetByID(ctx context.Context, id string) (Upload, error)
+	List(ctx context.Context, query UploadQuery) (UploadPage, error)
+	UpdateStatus(ctx context.Context, id, status string) error
+}
+
+func NewUploadID() (string, error) {
+	return randomString(12)
+}
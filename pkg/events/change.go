@@ -0,0 +1,38 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	TypeDocumentChanged    = "document.changed"
+	DocumentChangedVersion = 1
+)
+
+// Change operations, named after the MongoDB change stream operation types.
+const (
+	OperationInsert  = "insert"
+	OperationUpdate  = "update"
+	OperationReplace = "replace"
+	OperationDelete  = "delete"
+)
+
+// DocumentChanged is a change captured from a MongoDB collection. The
+// document and its key are relaxed extended JSON. Document holds the full
+// document after the change and is empty for deletes, UpdatedFields and
+// RemovedFields are only set for updates.
+type DocumentChanged struct {
+	Operation     string          `json:"operation"`
+	Database      string          `json:"database"`
+	Collection    string          `json:"collection"`
+	DocumentKey   json.RawMessage `json:"documentKey"`
+	Document      json.RawMessage `json:"document,omitempty"`
+	UpdatedFields json.RawMessage `json:"updatedFields,omitempty"`
+	RemovedFields []string        `json:"removedFields,omitempty"`
+	ClusterTime   time.Time       `json:"clusterTime"`
+}
+
+func NewDocumentChanged(change DocumentChanged) (Envelope, error) {
+	return New(TypeDocumentChanged, DocumentChangedVersion, "", change)
+}
@@ -0,0 +1,130 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"web-service/pkg/kafka"
+)
+
+const (
+	HeaderContentType = "content-type"
+	HeaderEventType   = "event-type"
+	HeaderVersion     = "event-version"
+)
+
+var ErrUnknownCodec = errors.New("events: unknown codec")
+
+// Codec serializes envelopes for the wire.
+type Codec interface {
+	ContentType() string
+	Marshal(e Envelope) ([]byte, error)
+	Unmarshal(b []byte) (Envelope, error)
+}
+
+var (
+	mu           sync.RWMutex
+	defaultCodec Codec = JSONCodec{}
+
+	codecs = map[string]Codec{
+		JSONCodec{}.ContentType():     JSONCodec{},
+		ProtobufCodec{}.ContentType(): ProtobufCodec{},
+	}
+)
+
+// Init selects the codec used for new messages by name, "json" or
+// "protobuf". Decoding always follows the message's content-type header.
+func Init(name string) error {
+	var codec Codec
+	switch name {
+	case "", "json":
+		codec = JSONCodec{}
+	case "protobuf":
+		codec = ProtobufCodec{}
+	default:
+		return fmt.Errorf("%w %q", ErrUnknownCodec, name)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	defaultCodec = codec
+	return nil
+}
+
+func getCodec() Codec {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return defaultCodec
+}
+
+// Encode builds the Kafka message for e, keyed by user.
+func Encode(topic string, e Envelope) (*kafka.Message, error) {
+	codec := getCodec()
+
+	value, err := codec.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+
+	return &kafka.Message{
+		Topic: topic,
+		Key:   e.Key(),
+		Value: value,
+		Headers: map[string]string{
+			HeaderContentType: codec.ContentType(),
+			HeaderEventType:   e.Type,
+			HeaderVersion:     strconv.Itoa(e.Version),
+		},
+	}, nil
+}
+
+// Decode reads an envelope from msg. Messages without a content-type header
+// are treated as JSON.
+func Decode(msg *kafka.Message) (Envelope, error) {
+	contentType := msg.Headers[HeaderContentType]
+	if contentType == "" {
+		contentType = JSONCodec{}.ContentType()
+	}
+
+	codec, ok := codecs[contentType]
+	if !ok {
+		return Envelope{}, fmt.Errorf("%w for content type %q", ErrUnknownCodec, contentType)
+	}
+	return codec.Unmarshal(msg.Value)
+}
+
+type JSONCodec struct{}
+
+func (JSONCodec) ContentType() string {
+	return "application/json"
+}
+
+func (JSONCodec) Marshal(e Envelope) ([]byte, error) {
+	return json.Marshal(e)
+}
+
+func (JSONCodec) Unmarshal(b []byte) (Envelope, error) {
+	var e Envelope
+	if err := json.Unmarshal(b, &e); err != nil {
+		return Envelope{}, fmt.Errorf("events: invalid JSON envelope: %w", err)
+	}
+	if e.ID == "" || e.Type == "" {
+		return Envelope{}, errors.New("events: envelope is missing id or type")
+	}
+	return e, nil
+}
+
+// Publish encodes e, produces it to topic on the default broker and waits
+// for the broker to acknowledge it.
+func Publish(ctx context.Context, topic string, e Envelope) error {
+	msg, err := Encode(topic, e)
+	if err != nil {
+		return err
+	}
+	return kafka.ProduceMessageSync(ctx, msg)
+}
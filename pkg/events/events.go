@@ -0,0 +1,49 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Envelope is the versioned wrapper around every event published by the
+// service. Data holds the JSON encoded payload of the given Type, and
+// Version is bumped whenever that payload changes incompatibly.
+type Envelope struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Version    int             `json:"version"`
+	UserID     string          `json:"userId,omitempty"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	Data       json.RawMessage `json:"data"`
+}
+
+func New(eventType string, version int, userID string, payload interface{}) (Envelope, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("events: unable to encode %s payload: %w", eventType, err)
+	}
+
+	return Envelope{
+		ID:         uuid.NewString(),
+		Type:       eventType,
+		Version:    version,
+		UserID:     userID,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	}, nil
+}
+
+// Key is the partitioning key, so all events of a user stay in order.
+func (e Envelope) Key() []byte {
+	return []byte(e.UserID)
+}
+
+func (e Envelope) DecodeData(dst interface{}) error {
+	if err := json.Unmarshal(e.Data, dst); err != nil {
+		return fmt.Errorf("events: unable to decode %s payload: %w", e.Type, err)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package events
+
+const (
+	TopicFileUploaded = "file_uploaded"
+
+	TypeFileUploaded    = "file.uploaded"
+	FileUploadedVersion = 1
+)
+
+type FileUploaded struct {
+	UploadID string `json:"uploadId,omitempty"`
+	FileID   string `json:"fileId"`
+	Storage  string `json:"storage"`
+	Name     string `json:"name"`
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType,omitempty"`
+	SHA256   string `json:"sha256,omitempty"`
+}
+
+func NewFileUploaded(userID string, file FileUploaded) (Envelope, error) {
+	return New(TypeFileUploaded, FileUploadedVersion, userID, file)
+}
@@ -0,0 +1,157 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+	"web-service/pkg/kafka"
+)
+
+var ErrHubClosed = errors.New("events: hub closed")
+
+// Hub fans events out to stream subscribers. It keeps the most recent
+// events so reconnecting clients can resume after their last event ID.
+type Hub struct {
+	mu      sync.Mutex
+	clients map[*Client]struct{}
+	history []Envelope
+	size    int
+	closed  bool
+}
+
+// Client receives the events matching its filter on C. C is closed when
+// the client falls behind by more than its buffer or the hub closes, so a
+// slow client never blocks the others.
+type Client struct {
+	C <-chan Envelope
+
+	c       chan Envelope
+	filter  func(Envelope) bool
+	dropped bool
+}
+
+// Dropped reports whether C was closed because the client fell behind.
+func (c *Client) Dropped() bool {
+	return c.dropped
+}
+
+func NewHub(historySize int) *Hub {
+	return &Hub{clients: make(map[*Client]struct{}), size: historySize}
+}
+
+// Subscribe registers a client and returns the retained events after
+// lastEventID that match filter. An unknown lastEventID replays nothing.
+func (h *Hub) Subscribe(filter func(Envelope) bool, lastEventID string, buffer int) (*Client, []Envelope, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, nil, ErrHubClosed
+	}
+
+	var replay []Envelope
+	if lastEventID != "" {
+		for i := len(h.history) - 1; i >= 0; i-- {
+			if h.history[i].ID != lastEventID {
+				continue
+			}
+			for _, e := range h.history[i+1:] {
+				if filter(e) {
+					replay = append(replay, e)
+				}
+			}
+			break
+		}
+	}
+
+	c := make(chan Envelope, buffer)
+	client := &Client{C: c, c: c, filter: filter}
+	h.clients[client] = struct{}{}
+
+	return client, replay, nil
+}
+
+func (h *Hub) Unsubscribe(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.clients[client]; ok {
+		delete(h.clients, client)
+		close(client.c)
+	}
+}
+
+// Publish delivers e to every matching client without blocking.
+func (h *Hub) Publish(e Envelope) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+
+	h.history = append(h.history, e)
+	if len(h.history) > h.size {
+		h.history = append(h.history[:0:0], h.history[len(h.history)-h.size:]...)
+	}
+
+	for client := range h.clients {
+		if !client.filter(e) {
+			continue
+		}
+
+		select {
+		case client.c <- e:
+		default:
+			client.dropped = true
+			delete(h.clients, client)
+			close(client.c)
+		}
+	}
+}
+
+// Close disconnects every client.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for client := range h.clients {
+		delete(h.clients, client)
+		close(client.c)
+	}
+}
+
+// Run publishes the events of sub until ctx is done, committing each one
+// once it is handed to the clients.
+func (h *Hub) Run(ctx context.Context, sub kafka.Subscription) {
+	for {
+		msg, err := sub.Fetch(ctx)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, kafka.ErrBrokerClosed) {
+				return
+			}
+			log.Printf("Failed to fetch stream events: %v", err)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		e, err := Decode(msg)
+		if err != nil {
+			log.Printf("Skipping undecodable event at %s[%d]@%d: %v", msg.Topic, msg.Partition, msg.Offset, err)
+		} else {
+			h.Publish(e)
+		}
+
+		if err := sub.Commit(ctx, msg); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to commit offset for %s: %v", msg.Topic, err)
+		}
+	}
+}
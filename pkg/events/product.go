@@ -0,0 +1,32 @@
+package events
+
+const (
+	TopicProductEvents = "product_events"
+
+	TypeProductCreated  = "product.created"
+	TypeProductUpdated  = "product.updated"
+	TypeProductDeleted  = "product.deleted"
+	ProductEventVersion = 1
+)
+
+type Product struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// ProductChanged carries the product before and after the change. Before is
+// nil for product.created and After is nil for product.deleted.
+type ProductChanged struct {
+	ProductID int      `json:"productId"`
+	Before    *Product `json:"before"`
+	After     *Product `json:"after"`
+}
+
+func NewProductChanged(eventType, userID string, productID int, before, after *Product) (Envelope, error) {
+	return New(eventType, ProductEventVersion, userID, ProductChanged{
+		ProductID: productID,
+		Before:    before,
+		After:     after,
+	})
+}
@@ -0,0 +1,113 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers of the Envelope message in envelope.proto.
+const (
+	fieldID         protowire.Number = 1
+	fieldType       protowire.Number = 2
+	fieldVersion    protowire.Number = 3
+	fieldUserID     protowire.Number = 4
+	fieldOccurredAt protowire.Number = 5
+	fieldData       protowire.Number = 6
+)
+
+// ProtobufCodec encodes envelopes as the Envelope message described in
+// envelope.proto. The payload stays JSON inside the data field.
+type ProtobufCodec struct{}
+
+func (ProtobufCodec) ContentType() string {
+	return "application/x-protobuf"
+}
+
+func (ProtobufCodec) Marshal(e Envelope) ([]byte, error) {
+	var b []byte
+
+	b = appendString(b, fieldID, e.ID)
+	b = appendString(b, fieldType, e.Type)
+	if e.Version != 0 {
+		b = protowire.AppendTag(b, fieldVersion, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(e.Version))
+	}
+	b = appendString(b, fieldUserID, e.UserID)
+	if !e.OccurredAt.IsZero() {
+		b = protowire.AppendTag(b, fieldOccurredAt, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(e.OccurredAt.UnixNano()))
+	}
+	if len(e.Data) > 0 {
+		b = protowire.AppendTag(b, fieldData, protowire.BytesType)
+		b = protowire.AppendBytes(b, e.Data)
+	}
+
+	return b, nil
+}
+
+func appendString(b []byte, num protowire.Number, value string) []byte {
+	if value == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, value)
+}
+
+func (ProtobufCodec) Unmarshal(b []byte) (Envelope, error) {
+	var e Envelope
+
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return Envelope{}, fmt.Errorf("events: invalid protobuf envelope: %w", protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		switch {
+		case typ == protowire.BytesType && (num == fieldID || num == fieldType || num == fieldUserID || num == fieldData):
+			value, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return Envelope{}, fmt.Errorf("events: invalid protobuf envelope: %w", protowire.ParseError(n))
+			}
+			b = b[n:]
+
+			switch num {
+			case fieldID:
+				e.ID = string(value)
+			case fieldType:
+				e.Type = string(value)
+			case fieldUserID:
+				e.UserID = string(value)
+			case fieldData:
+				e.Data = append([]byte(nil), value...)
+			}
+		case typ == protowire.VarintType && (num == fieldVersion || num == fieldOccurredAt):
+			value, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return Envelope{}, fmt.Errorf("events: invalid protobuf envelope: %w", protowire.ParseError(n))
+			}
+			b = b[n:]
+
+			if num == fieldVersion {
+				e.Version = int(value)
+			} else {
+				e.OccurredAt = time.Unix(0, int64(value)).UTC()
+			}
+		default:
+			// Skip fields added by newer producers.
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return Envelope{}, fmt.Errorf("events: invalid protobuf envelope: %w", protowire.ParseError(n))
+			}
+			b = b[n:]
+		}
+	}
+
+	if e.ID == "" || e.Type == "" {
+		return Envelope{}, errors.New("events: envelope is missing id or type")
+	}
+	return e, nil
+}
@@ -0,0 +1,324 @@
+package googledrive
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	resumableUploadURL = "https://www.googleapis.com/upload/drive/v3/files?uploadType=resumable"
+
+	// ChunkAlignment is the granularity Drive requires for every chunk but
+	// the last one.
+	ChunkAlignment = 256 * 1024
+
+	sessionTTL = 7 * 24 * time.Hour
+)
+
+const (
+	UploadActive    = "active"
+	UploadCompleted = "completed"
+)
+
+var (
+	ErrUploadNotFound = errors.New("upload session not found")
+	ErrUploadExpired  = errors.New("upload session expired")
+	ErrUploadBusy     = errors.New("upload session is receiving another chunk")
+)
+
+type UploadSession struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"-"`
+	Name       string    `json:"name"`
+	MimeType   string    `json:"mimeType"`
+	Size       int64     `json:"size"`
+	Received   int64     `json:"received"`
+	Status     string    `json:"status"`
+	FileID     string    `json:"fileId,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+	SessionURI string    `json:"-"`
+
+	// HashState is the marshaled SHA-256 state of the first HashedBytes
+	// bytes. It is nil once the checksum can no longer be computed, for
+	// example when Drive only stored part of a chunk.
+	HashState   []byte `json:"-"`
+	HashedBytes int64  `json:"-"`
+
+	busy bool
+}
+
+// UploadProgress is Drive's view of a resumable upload.
+type UploadProgress struct {
+	Received int64
+	Complete bool
+	FileID   string
+}
+
+// StartResumableUpload opens a Drive resumable upload session and returns
+// its session URI.
+func StartResumableUpload(ctx context.Context, client *http.Client, name, mimeType string, size int64, parents []string) (string, error) {
+	metadata := map[string]interface{}{"name": name}
+	if mimeType != "" {
+		metadata["mimeType"] = mimeType
+	}
+	if len(parents) > 0 {
+		metadata["parents"] = parents
+	}
+
+	body, err := json.Marshal(metadata)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, resumableUploadURL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("X-Upload-Content-Length", strconv.FormatInt(size, 10))
+	if mimeType != "" {
+		req.Header.Set("X-Upload-Content-Type", mimeType)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", driveError(resp)
+	}
+
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return "", errors.New("drive did not return an upload session URI")
+	}
+	return location, nil
+}
+
+// UploadChunk sends bytes [start, start+length) of a total-byte upload.
+func UploadChunk(ctx context.Context, client *http.Client, sessionURI string, chunk io.Reader, start, length, total int64) (UploadProgress, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, sessionURI, chunk)
+	if err != nil {
+		return UploadProgress{}, err
+	}
+	req.ContentLength = length
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, start+length-1, total))
+
+	return sendProgressRequest(client, req)
+}
+
+// QueryUploadStatus asks Drive how many bytes of the session it has stored.
+func QueryUploadStatus(ctx context.Context, client *http.Client, sessionURI string, total int64) (UploadProgress, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, sessionURI, nil)
+	if err != nil {
+		return UploadProgress{}, err
+	}
+	req.ContentLength = 0
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", total))
+
+	return sendProgressRequest(client, req)
+}
+
+func CancelResumableUpload(ctx context.Context, client *http.Client, sessionURI string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, sessionURI, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Drive answers 499 once the session has been cancelled.
+	if resp.StatusCode >= 300 && resp.StatusCode != 499 && resp.StatusCode != http.StatusNotFound {
+		return driveError(resp)
+	}
+	return nil
+}
+
+func sendProgressRequest(client *http.Client, req *http.Request) (UploadProgress, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return UploadProgress{}, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		var file struct {
+			ID string `json:"id"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&file); err != nil {
+			return UploadProgress{}, fmt.Errorf("unable to decode uploaded file: %w", err)
+		}
+		return UploadProgress{Complete: true, FileID: file.ID}, nil
+	case http.StatusPermanentRedirect:
+		return UploadProgress{Received: parseRangeHeader(resp.Header.Get("Range"))}, nil
+	case http.StatusNotFound, http.StatusGone:
+		return UploadProgress{}, ErrUploadExpired
+	}
+
+	return UploadProgress{}, driveError(resp)
+}
+
+// parseRangeHeader converts Drive's "bytes=0-N" into the received byte
+// count N+1.
+func parseRangeHeader(value string) int64 {
+	_, last, ok := strings.Cut(strings.TrimPrefix(value, "bytes="), "-")
+	if !ok {
+		return 0
+	}
+
+	n, err := strconv.ParseInt(last, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n + 1
+}
+
+func driveError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return fmt.Errorf("drive returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
+
+// UploadSessionStore tracks resumable uploads in memory so clients can
+// resume after a disconnect.
+type UploadSessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*UploadSession
+}
+
+func NewUploadSessionStore() *UploadSessionStore {
+	return &UploadSessionStore{sessions: make(map[string]*UploadSession)}
+}
+
+func (s *UploadSessionStore) Create(session UploadSession) (UploadSession, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return UploadSession{}, err
+	}
+
+	now := time.Now().UTC()
+	session.ID = hex.EncodeToString(b)
+	session.Status = UploadActive
+	session.CreatedAt = now
+	session.UpdatedAt = now
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, existing := range s.sessions {
+		if now.Sub(existing.CreatedAt) > sessionTTL {
+			delete(s.sessions, id)
+		}
+	}
+	s.sessions[session.ID] = &session
+
+	return session, nil
+}
+
+// Get returns the session only when it belongs to userID.
+func (s *UploadSessionStore) Get(id, userID string) (UploadSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok || session.UserID != userID {
+		return UploadSession{}, ErrUploadNotFound
+	}
+	return *session, nil
+}
+
+// Acquire reserves the session for one request at a time. Callers must
+// finish with Release.
+func (s *UploadSessionStore) Acquire(id, userID string) (UploadSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok || session.UserID != userID {
+		return UploadSession{}, ErrUploadNotFound
+	}
+	if session.busy {
+		return UploadSession{}, ErrUploadBusy
+	}
+
+	session.busy = true
+	return *session, nil
+}
+
+func (s *UploadSessionStore) Release(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session, ok := s.sessions[id]; ok {
+		session.busy = false
+	}
+}
+
+// SetHashState must be called while the session is acquired.
+func (s *UploadSessionStore) SetHashState(id string, state []byte, hashed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session, ok := s.sessions[id]; ok {
+		session.HashState = state
+		session.HashedBytes = hashed
+	}
+}
+
+// SetMimeType replaces the type declared by the client with the one sniffed
+// from the first chunk. It must be called while the session is acquired.
+func (s *UploadSessionStore) SetMimeType(id, mimeType string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session, ok := s.sessions[id]; ok {
+		session.MimeType = mimeType
+	}
+}
+
+// Update must be called while the session is acquired.
+func (s *UploadSessionStore) Update(id string, progress UploadProgress) (UploadSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return UploadSession{}, ErrUploadNotFound
+	}
+
+	if progress.Complete {
+		session.Status = UploadCompleted
+		session.Received = session.Size
+		session.FileID = progress.FileID
+	} else {
+		session.Received = progress.Received
+	}
+	session.UpdatedAt = time.Now().UTC()
+
+	return *session, nil
+}
+
+func (s *UploadSessionStore) Delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, id)
+}
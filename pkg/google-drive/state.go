@@ -0,0 +1,64 @@
+package googledrive
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"sync"
+	"time"
+)
+
+const stateTTL = 10 * time.Minute
+
+type pendingState struct {
+	userID    string
+	expiresAt time.Time
+}
+
+// StateStore issues one-time OAuth state values bound to the user who
+// started the authorization flow.
+type StateStore struct {
+	mu     sync.Mutex
+	states map[string]pendingState
+}
+
+func NewStateStore() *StateStore {
+	return &StateStore{states: make(map[string]pendingState)}
+}
+
+func (s *StateStore) New(userID string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	state := base64.RawURLEncoding.EncodeToString(b)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, pending := range s.states {
+		if now.After(pending.expiresAt) {
+			delete(s.states, key)
+		}
+	}
+	s.states[state] = pendingState{userID: userID, expiresAt: now.Add(stateTTL)}
+
+	return state, nil
+}
+
+// Consume returns the user bound to state and invalidates it.
+func (s *StateStore) Consume(state string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending, ok := s.states[state]
+	if !ok {
+		return "", false
+	}
+	delete(s.states, state)
+
+	if time.Now().After(pending.expiresAt) {
+		return "", false
+	}
+	return pending.userID, true
+}
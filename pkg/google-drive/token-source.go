@@ -0,0 +1,127 @@
+package googledrive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+var ErrReauthRequired = errors.New("google drive authorization was revoked")
+
+// TokenSources hands out one persisting token source per user, so that
+// concurrent requests share a single refresh and every refreshed token is
+// written back to the store.
+type TokenSources struct {
+	mu      sync.Mutex
+	store   TokenStore
+	sources map[string]*persistingTokenSource
+}
+
+func NewTokenSources(store TokenStore) *TokenSources {
+	return &TokenSources{store: store, sources: make(map[string]*persistingTokenSource)}
+}
+
+func (m *TokenSources) TokenSource(ctx context.Context, userID string) (oauth2.TokenSource, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if source, ok := m.sources[userID]; ok {
+		return source, nil
+	}
+
+	token, err := m.store.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	source := &persistingTokenSource{owner: m, userID: userID, current: token}
+	m.sources[userID] = source
+	return source, nil
+}
+
+// Client returns an HTTP client authorized as userID, failing early with
+// ErrTokenNotFound or ErrReauthRequired.
+func (m *TokenSources) Client(ctx context.Context, userID string) (*http.Client, error) {
+	source, err := m.TokenSource(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := source.Token(); err != nil {
+		return nil, err
+	}
+
+	return oauth2.NewClient(context.Background(), source), nil
+}
+
+// Save stores a newly authorized token and drops the cached source.
+func (m *TokenSources) Save(ctx context.Context, userID string, token *oauth2.Token) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.sources, userID)
+	return m.store.Save(ctx, userID, token)
+}
+
+func (m *TokenSources) forget(userID string, source *persistingTokenSource) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.sources[userID] == source {
+		delete(m.sources, userID)
+	}
+}
+
+type persistingTokenSource struct {
+	mu      sync.Mutex
+	owner   *TokenSources
+	userID  string
+	current *oauth2.Token
+}
+
+func (s *persistingTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current.Valid() {
+		return s.current, nil
+	}
+
+	token, err := OauthConfig.TokenSource(context.Background(), s.current).Token()
+	if err != nil {
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) && retrieveErr.ErrorCode == "invalid_grant" {
+			s.revoke()
+			return nil, fmt.Errorf("%w: %v", ErrReauthRequired, err)
+		}
+		return nil, err
+	}
+
+	s.current = token
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.owner.store.Save(ctx, s.userID, token); err != nil {
+		log.Printf("Failed to persist refreshed Google Drive token for %s: %v", s.userID, err)
+	}
+
+	return token, nil
+}
+
+func (s *persistingTokenSource) revoke() {
+	s.owner.forget(s.userID, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.owner.store.Delete(ctx, s.userID); err != nil {
+		log.Printf("Failed to delete revoked Google Drive token for %s: %v", s.userID, err)
+	}
+}
@@ -0,0 +1,183 @@
+package googledrive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/oauth2"
+)
+
+var ErrTokenNotFound = errors.New("google drive token not found")
+
+// TokenStore keeps one OAuth token per authenticated user.
+type TokenStore interface {
+	Get(ctx context.Context, userID string) (*oauth2.Token, error)
+	Save(ctx context.Context, userID string, token *oauth2.Token) error
+	Delete(ctx context.Context, userID string) error
+}
+
+// FileTokenStore keeps all tokens in a single JSON file keyed by user ID.
+type FileTokenStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileTokenStore(path string) (*FileTokenStore, error) {
+	if path == "" {
+		return nil, errors.New("missing GOOGLE_DRIVE_TOKEN_PATH environment variable")
+	}
+	return &FileTokenStore{path: path}, nil
+}
+
+func (s *FileTokenStore) Get(ctx context.Context, userID string) (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	token, ok := tokens[userID]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+	return token, nil
+}
+
+func (s *FileTokenStore) Save(ctx context.Context, userID string, token *oauth2.Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	tokens[userID] = token
+	return s.write(tokens)
+}
+
+func (s *FileTokenStore) Delete(ctx context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	delete(tokens, userID)
+	return s.write(tokens)
+}
+
+func (s *FileTokenStore) load() (map[string]*oauth2.Token, error) {
+	tokens := map[string]*oauth2.Token{}
+
+	b, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read token file: %w", err)
+	}
+
+	if len(b) == 0 {
+		return tokens, nil
+	}
+
+	var legacy oauth2.Token
+	if err := json.Unmarshal(b, &legacy); err == nil && legacy.AccessToken != "" {
+		log.Printf("Ignoring single-user token file %s, users must authorize Google Drive again", s.path)
+		return tokens, nil
+	}
+
+	if err := json.Unmarshal(b, &tokens); err != nil {
+		return nil, fmt.Errorf("unable to decode token file: %w", err)
+	}
+	return tokens, nil
+}
+
+// write replaces the token file atomically so readers never observe a
+// partially written file.
+func (s *FileTokenStore) write(tokens map[string]*oauth2.Token) error {
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("unable to create directory for token file: %w", err)
+	}
+
+	f, err := os.CreateTemp(dir, filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("unable to create token file: %w", err)
+	}
+	tmpPath := f.Name()
+	defer os.Remove(tmpPath)
+
+	if err := json.NewEncoder(f).Encode(tokens); err != nil {
+		f.Close()
+		return fmt.Errorf("unable to write token to file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("unable to sync token file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("unable to close token file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("unable to replace token file: %w", err)
+	}
+	return nil
+}
+
+const tokenCollection = "google_drive_tokens"
+
+type MongoTokenStore struct {
+	collection *mongo.Collection
+}
+
+type tokenDocument struct {
+	UserID    string        `bson:"_id"`
+	Token     *oauth2.Token `bson:"token"`
+	UpdatedAt time.Time     `bson:"updatedAt"`
+}
+
+func NewMongoTokenStore(db *mongo.Database) *MongoTokenStore {
+	return &MongoTokenStore{collection: db.Collection(tokenCollection)}
+}
+
+func (s *MongoTokenStore) Get(ctx context.Context, userID string) (*oauth2.Token, error) {
+	var doc tokenDocument
+
+	err := s.collection.FindOne(ctx, bson.D{{Key: "_id", Value: userID}}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrTokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return doc.Token, nil
+}
+
+func (s *MongoTokenStore) Save(ctx context.Context, userID string, token *oauth2.Token) error {
+	doc := tokenDocument{UserID: userID, Token: token, UpdatedAt: time.Now().UTC()}
+
+	_, err := s.collection.ReplaceOne(ctx, bson.D{{Key: "_id", Value: userID}}, doc, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (s *MongoTokenStore) Delete(ctx context.Context, userID string) error {
+	_, err := s.collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: userID}})
+	return err
+}
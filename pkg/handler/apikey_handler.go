@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"web-service/pkg/data"
+	"web-service/pkg/middlewares"
+	"web-service/pkg/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type APIKeyHandler struct {
+	repo data.APIKeyRepository
+}
+
+type issueAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+type issuedAPIKey struct {
+	Key    string      `json:"key"`
+	APIKey data.APIKey `json:"apiKey"`
+}
+
+func NewAPIKeyHandler(repo data.APIKeyRepository) *APIKeyHandler {
+	return &APIKeyHandler{repo: repo}
+}
+
+func (h *APIKeyHandler) listAPIKeys(w http.ResponseWriter, r *http.Request) utils.Response {
+	keys, err := h.repo.List(r.Context())
+	if err != nil {
+		log.Printf("Failed to list API keys: %v", err)
+		return utils.InternalServerError("Failed to get API keys")
+	}
+
+	return utils.SuccessResponse("Get all API keys successfully", keys)
+}
+
+func (h *APIKeyHandler) issueAPIKey(w http.ResponseWriter, r *http.Request) utils.Response {
+	var req issueAPIKeyRequest
+
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		return utils.DecodeErrorResponse(err)
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return utils.ValidationError("Validation failed", map[string]string{"expiresAt": "must be in the future"})
+	}
+
+	return h.issue(r, "Create API key successfully", req.Name, req.Scopes, req.ExpiresAt)
+}
+
+func (h *APIKeyHandler) revokeAPIKey(w http.ResponseWriter, r *http.Request) utils.Response {
+	id := mux.Vars(r)["id"]
+
+	err := h.repo.Revoke(r.Context(), id, time.Now().UTC())
+	if errors.Is(err, data.ErrAPIKeyNotFound) {
+		return utils.NotFoundError(fmt.Sprintf("API key %s not found", id), nil)
+	}
+	if err != nil {
+		log.Printf("Failed to revoke API key %s: %v", id, err)
+		return utils.InternalServerError("Failed to revoke API key")
+	}
+
+	return utils.SuccessResponse(fmt.Sprintf("Revoke API key %s successfully", id), nil)
+}
+
+// rotateAPIKey issues a replacement with the same name, scopes and expiry
+// and revokes the old key.
+func (h *APIKeyHandler) rotateAPIKey(w http.ResponseWriter, r *http.Request) utils.Response {
+	id := mux.Vars(r)["id"]
+
+	old, err := h.repo.GetByID(r.Context(), id)
+	if errors.Is(err, data.ErrAPIKeyNotFound) {
+		return utils.NotFoundError(fmt.Sprintf("API key %s not found", id), nil)
+	}
+	if err != nil {
+		log.Printf("Failed to get API key %s: %v", id, err)
+		return utils.InternalServerError("Failed to rotate API key")
+	}
+
+	if !old.Active(time.Now()) {
+		return utils.ConflictError(fmt.Sprintf("API key %s is revoked or expired", id), nil)
+	}
+
+	response := h.issue(r, fmt.Sprintf("Rotate API key %s successfully", id), old.Name, old.Scopes, old.ExpiresAt)
+	if response.StatusCode != http.StatusCreated {
+		return response
+	}
+
+	if err := h.repo.Revoke(r.Context(), id, time.Now().UTC()); err != nil {
+		log.Printf("Failed to revoke rotated API key %s: %v", id, err)
+		return utils.InternalServerError("Failed to revoke rotated API key")
+	}
+
+	return response
+}
+
+// issue also checks the scopes of rotated keys, which may predate the list
+// of allowed scopes.
+func (h *APIKeyHandler) issue(r *http.Request, message, name string, scopes []string, expiresAt *time.Time) utils.Response {
+	for _, scope := range scopes {
+		if !slices.Contains(data.APIKeyScopes, scope) {
+			return utils.ValidationError("Validation failed", map[string]string{
+				"scopes": fmt.Sprintf("%q can't be granted to an API key, allowed scopes are %s", scope, strings.Join(data.APIKeyScopes, ", ")),
+			})
+		}
+	}
+
+	key, plaintext, err := data.NewAPIKey(name, scopes, expiresAt)
+	if err != nil {
+		return utils.InternalServerError("Failed to generate API key")
+	}
+
+	if err := h.repo.Create(r.Context(), key); err != nil {
+		log.Printf("Failed to store API key: %v", err)
+		return utils.InternalServerError("Failed to create API key")
+	}
+
+	return utils.CreatedResponse(message, issuedAPIKey{Key: plaintext, APIKey: key})
+}
+
+func APIKeyRoutes(r *mux.Router, repo data.APIKeyRepository) {
+	h := NewAPIKeyHandler(repo)
+	apiKeyRouter := r.PathPrefix("/admin/api-keys").Subrouter()
+
+	apiKeyRouter.HandleFunc("", middlewares.RequireRoles(utils.WrapHandler(h.listAPIKeys), "admin")).Methods(http.MethodGet).Name("listAPIKeys")
+	apiKeyRouter.HandleFunc("", middlewares.RequireRoles(utils.WrapHandler(h.issueAPIKey), "admin")).Methods(http.MethodPost).Name("issueAPIKey")
+	apiKeyRouter.HandleFunc("/{id}", middlewares.RequireRoles(utils.WrapHandler(h.revokeAPIKey), "admin")).Methods(http.MethodDelete).Name("revokeAPIKey")
+	apiKeyRouter.HandleFunc("/{id}/rotate", middlewares.RequireRoles(utils.WrapHandler(h.rotateAPIKey), "admin")).Methods(http.MethodPost).Name("rotateAPIKey")
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"web-service/pkg/data"
+	"web-service/pkg/kafka"
+	"web-service/pkg/middlewares"
+	"web-service/pkg/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// replayTimeout bounds the wait for the broker to acknowledge a replay.
+const replayTimeout = 10 * time.Second
+
+type DeadLetterHandler struct {
+	repo data.DeadLetterRepository
+}
+
+func NewDeadLetterHandler(repo data.DeadLetterRepository) *DeadLetterHandler {
+	return &DeadLetterHandler{repo: repo}
+}
+
+func (h *DeadLetterHandler) listDeadLetters(w http.ResponseWriter, r *http.Request) utils.Response {
+	query := r.URL.Query()
+
+	page, err := utils.ParsePage(query)
+	if err != nil {
+		return utils.BadRequestError("Invalid query: "+err.Error(), nil)
+	}
+
+	var replayed *bool
+	if value := query.Get("replayed"); value != "" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return utils.BadRequestError("Invalid query: replayed must be true or false", nil)
+		}
+		replayed = &b
+	}
+
+	result, err := h.repo.List(r.Context(), data.DeadLetterQuery{
+		SourceTopic: query.Get("topic"),
+		Replayed:    replayed,
+		Page:        page,
+	})
+	if err != nil {
+		log.Printf("Failed to list dead letters: %v", err)
+		return utils.InternalServerError("Failed to get dead letters")
+	}
+
+	pagination := utils.NewPagination(page, result.Total)
+	utils.SetLinkHeader(w, r, pagination)
+
+	return utils.PaginatedResponse("Get all dead letters successfully", result.Items, pagination)
+}
+
+func (h *DeadLetterHandler) getDeadLetter(w http.ResponseWriter, r *http.Request) utils.Response {
+	dl, response, ok := h.find(r)
+	if !ok {
+		return response
+	}
+
+	return utils.SuccessResponse("Get dead letter successfully", dl)
+}
+
+// replayDeadLetter produces the original message back onto its source
+// topic, where the workers pick it up again.
+func (h *DeadLetterHandler) replayDeadLetter(w http.ResponseWriter, r *http.Request) utils.Response {
+	dl, response, ok := h.find(r)
+	if !ok {
+		return response
+	}
+
+	headers := make(map[string]string, len(dl.Headers)+1)
+	for k, v := range dl.Headers {
+		headers[k] = v
+	}
+	headers[kafka.HeaderDLQReplayCount] = strconv.Itoa(dl.ReplayCount + 1)
+
+	ctx, cancel := context.WithTimeout(r.Context(), replayTimeout)
+	defer cancel()
+
+	err := kafka.ProduceMessageSync(ctx, &kafka.Message{
+		Topic:   dl.SourceTopic,
+		Key:     dl.Key,
+		Value:   dl.Value,
+		Headers: headers,
+	})
+	if err != nil {
+		log.Printf("Failed to replay dead letter %s: %v", dl.ID, err)
+		return utils.ServiceUnavailableError("Failed to replay dead letter: " + err.Error())
+	}
+
+	if err := h.repo.MarkReplayed(r.Context(), dl.ID, time.Now().UTC()); err != nil {
+		log.Printf("Failed to mark dead letter %s as replayed: %v", dl.ID, err)
+	}
+
+	return utils.SuccessResponse(fmt.Sprintf("Dead letter %s replayed to %s", dl.ID, dl.SourceTopic), nil)
+}
+
+func (h *DeadLetterHandler) deleteDeadLetter(w http.ResponseWriter, r *http.Request) utils.Response {
+	id := mux.Vars(r)["id"]
+
+	err := h.repo.Delete(r.Context(), id)
+	if errors.Is(err, data.ErrDeadLetterNotFound) {
+		return utils.NotFoundError(fmt.Sprintf("Dead letter %s not found", id), nil)
+	}
+	if err != nil {
+		log.Printf("Failed to delete dead letter %s: %v", id, err)
+		return utils.InternalServerError("Failed to delete dead letter")
+	}
+
+	return utils.SuccessResponse(fmt.Sprintf("Dead letter %s deleted", id), nil)
+}
+
+func (h *DeadLetterHandler) find(r *http.Request) (data.DeadLetter, utils.Response, bool) {
+	id := mux.Vars(r)["id"]
+
+	dl, err := h.repo.GetByID(r.Context(), id)
+	if errors.Is(err, data.ErrDeadLetterNotFound) {
+		return dl, utils.NotFoundError(fmt.Sprintf("Dead letter %s not found", id), nil), false
+	}
+	if err != nil {
+		log.Printf("Failed to get dead letter %s: %v", id, err)
+		return dl, utils.InternalServerError("Failed to get dead letter"), false
+	}
+	return dl, utils.Response{}, true
+}
+
+func DeadLetterRoutes(r *mux.Router, repo data.DeadLetterRepository) {
+	h := NewDeadLetterHandler(repo)
+	dlqRouter := r.PathPrefix("/admin/dlq").Subrouter()
+
+	dlqRouter.HandleFunc("", middlewares.RequireRoles(utils.WrapHandler(h.listDeadLetters), "admin")).Methods(http.MethodGet)
+	dlqRouter.HandleFunc("/{id}", middlewares.RequireRoles(utils.WrapHandler(h.getDeadLetter), "admin")).Methods(http.MethodGet)
+	dlqRouter.HandleFunc("/{id}", middlewares.RequireRoles(utils.WrapHandler(h.deleteDeadLetter), "admin")).Methods(http.MethodDelete)
+	dlqRouter.HandleFunc("/{id}/replay", middlewares.RequireRoles(utils.WrapHandler(h.replayDeadLetter), "admin")).Methods(http.MethodPost)
+}
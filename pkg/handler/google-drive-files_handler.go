@@ -0,0 +1,266 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	googledrive "web-service/pkg/google-drive"
+	"web-service/pkg/utils"
+
+	"github.com/gorilla/mux"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	folderMimeType = "application/vnd.google-apps.folder"
+	fileFields     = "id, name, mimeType, size, parents, createdTime, modifiedTime, trashed"
+
+	downloadTimeout = 30 * time.Minute
+)
+
+// driveIDPattern matches Drive file IDs and the "root" alias. IDs go into
+// query strings, so anything else is rejected rather than escaped.
+var driveIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+type driveFileList struct {
+	Files         []*drive.File `json:"files"`
+	NextPageToken string        `json:"nextPageToken,omitempty"`
+}
+
+type updateFileRequest struct {
+	Name     string `json:"name" validate:"max=255"`
+	ParentID string `json:"parentId"`
+}
+
+type createFolderRequest struct {
+	Name     string `json:"name" validate:"required,max=255"`
+	ParentID string `json:"parentId"`
+}
+
+func (h *GoogleDriveHandler) driveService(r *http.Request) (*drive.Service, error) {
+	client, err := h.getClient(r.Context(), currentUserID(r))
+	if err != nil {
+		return nil, err
+	}
+	return drive.New(client)
+}
+
+// driveErrorResponse maps Drive API failures to a response.
+func (h *GoogleDriveHandler) driveErrorResponse(r *http.Request, err error, message string) utils.Response {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case http.StatusNotFound:
+			return utils.NotFoundError("File not found", nil)
+		case http.StatusForbidden:
+			return utils.ForbiddenError(message + ": " + apiErr.Message)
+		case http.StatusBadRequest:
+			return utils.BadRequestError(message+": "+apiErr.Message, nil)
+		}
+	}
+
+	if errors.Is(err, googledrive.ErrReauthRequired) || errors.Is(err, googledrive.ErrTokenNotFound) {
+		return h.clientErrorResponse(r, err)
+	}
+
+	log.Printf("%s: %v", message, err)
+	return utils.InternalServerError(message + ": " + err.Error())
+}
+
+func (h *GoogleDriveHandler) listFiles(w http.ResponseWriter, r *http.Request) utils.Response {
+	srv, err := h.driveService(r)
+	if err != nil {
+		return h.clientErrorResponse(r, err)
+	}
+
+	query := r.URL.Query()
+	call := srv.Files.List().
+		Fields(googleapi.Field("nextPageToken, files(" + fileFields + ")")).
+		PageToken(query.Get("pageToken"))
+
+	q := query.Get("q")
+	if folderID := query.Get("folderId"); folderID != "" {
+		if !driveIDPattern.MatchString(folderID) {
+			return utils.BadRequestError("Invalid folderId", nil)
+		}
+		parentQuery := fmt.Sprintf("'%s' in parents", folderID)
+		if q != "" {
+			q = "(" + q + ") and " + parentQuery
+		} else {
+			q = parentQuery
+		}
+	}
+	if q != "" {
+		call = call.Q(q)
+	}
+
+	if value := query.Get("pageSize"); value != "" {
+		pageSize, err := strconv.Atoi(value)
+		if err != nil || pageSize < 1 || pageSize > 1000 {
+			return utils.BadRequestError("pageSize must be between 1 and 1000", nil)
+		}
+		call = call.PageSize(int64(pageSize))
+	}
+
+	list, err := call.Context(r.Context()).Do()
+	if err != nil {
+		return h.driveErrorResponse(r, err, "Unable to list files")
+	}
+
+	return utils.SuccessResponse("Get files successfully", driveFileList{Files: list.Files, NextPageToken: list.NextPageToken})
+}
+
+func (h *GoogleDriveHandler) getFile(w http.ResponseWriter, r *http.Request) utils.Response {
+	srv, err := h.driveService(r)
+	if err != nil {
+		return h.clientErrorResponse(r, err)
+	}
+
+	id := mux.Vars(r)["id"]
+	file, err := srv.Files.Get(id).Fields(fileFields).Context(r.Context()).Do()
+	if err != nil {
+		return h.driveErrorResponse(r, err, "Unable to get file")
+	}
+
+	return utils.SuccessResponse(fmt.Sprintf("Get file %s successfully", id), file)
+}
+
+// downloadFile streams the file content without buffering it, so errors
+// after the first byte can only be logged.
+func (h *GoogleDriveHandler) downloadFile(w http.ResponseWriter, r *http.Request) {
+	respond := func(response utils.Response) {
+		utils.ResponseJson(w, response.StatusCode, response)
+	}
+
+	srv, err := h.driveService(r)
+	if err != nil {
+		respond(h.clientErrorResponse(r, err))
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	file, err := srv.Files.Get(id).Fields("id, name, mimeType, size").Context(r.Context()).Do()
+	if err != nil {
+		respond(h.driveErrorResponse(r, err, "Unable to get file"))
+		return
+	}
+
+	if file.MimeType == folderMimeType {
+		respond(utils.BadRequestError("Folders cannot be downloaded", nil))
+		return
+	}
+
+	resp, err := srv.Files.Get(id).Context(r.Context()).Download()
+	if err != nil {
+		respond(h.driveErrorResponse(r, err, "Unable to download file"))
+		return
+	}
+	defer resp.Body.Close()
+
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(downloadTimeout))
+
+	w.Header().Set("Content-Type", file.MimeType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": file.Name}))
+	if resp.ContentLength >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(resp.ContentLength, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		log.Printf("Failed to stream file %s: %v", id, err)
+	}
+}
+
+// deleteFile moves the file to the trash unless permanent=true is given.
+func (h *GoogleDriveHandler) deleteFile(w http.ResponseWriter, r *http.Request) utils.Response {
+	srv, err := h.driveService(r)
+	if err != nil {
+		return h.clientErrorResponse(r, err)
+	}
+
+	id := mux.Vars(r)["id"]
+
+	if r.URL.Query().Get("permanent") == "true" {
+		if err := srv.Files.Delete(id).Context(r.Context()).Do(); err != nil {
+			return h.driveErrorResponse(r, err, "Unable to delete file")
+		}
+		return utils.SuccessResponse(fmt.Sprintf("Delete file %s successfully", id), nil)
+	}
+
+	file, err := srv.Files.Update(id, &drive.File{Trashed: true}).Fields(fileFields).Context(r.Context()).Do()
+	if err != nil {
+		return h.driveErrorResponse(r, err, "Unable to trash file")
+	}
+
+	return utils.SuccessResponse(fmt.Sprintf("Move file %s to trash successfully", id), file)
+}
+
+// updateFile renames the file and/or moves it to parentId.
+func (h *GoogleDriveHandler) updateFile(w http.ResponseWriter, r *http.Request) utils.Response {
+	var req updateFileRequest
+
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		return utils.DecodeErrorResponse(err)
+	}
+
+	if req.Name == "" && req.ParentID == "" {
+		return utils.ValidationError("Validation failed", map[string]string{"name": "name or parentId is required"})
+	}
+
+	srv, err := h.driveService(r)
+	if err != nil {
+		return h.clientErrorResponse(r, err)
+	}
+
+	id := mux.Vars(r)["id"]
+	call := srv.Files.Update(id, &drive.File{Name: req.Name}).Fields(fileFields)
+
+	if req.ParentID != "" {
+		current, err := srv.Files.Get(id).Fields("parents").Context(r.Context()).Do()
+		if err != nil {
+			return h.driveErrorResponse(r, err, "Unable to get file")
+		}
+
+		call = call.AddParents(req.ParentID).RemoveParents(strings.Join(current.Parents, ","))
+	}
+
+	file, err := call.Context(r.Context()).Do()
+	if err != nil {
+		return h.driveErrorResponse(r, err, "Unable to update file")
+	}
+
+	return utils.SuccessResponse(fmt.Sprintf("Update file %s successfully", id), file)
+}
+
+func (h *GoogleDriveHandler) createFolder(w http.ResponseWriter, r *http.Request) utils.Response {
+	var req createFolderRequest
+
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		return utils.DecodeErrorResponse(err)
+	}
+
+	srv, err := h.driveService(r)
+	if err != nil {
+		return h.clientErrorResponse(r, err)
+	}
+
+	folder := &drive.File{Name: req.Name, MimeType: folderMimeType}
+	if req.ParentID != "" {
+		folder.Parents = []string{req.ParentID}
+	}
+
+	created, err := srv.Files.Create(folder).Fields(fileFields).Context(r.Context()).Do()
+	if err != nil {
+		return h.driveErrorResponse(r, err, "Unable to create folder")
+	}
+
+	return utils.CreatedResponse("Create folder successfully", created)
+}
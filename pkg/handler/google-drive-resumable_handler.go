@@ -0,0 +1,337 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+	"web-service/pkg/data"
+	googledrive "web-service/pkg/google-drive"
+	"web-service/pkg/storage"
+	"web-service/pkg/utils"
+
+	"github.com/gorilla/mux"
+)
+
+const chunkTimeout = 10 * time.Minute
+
+var contentRangePattern = regexp.MustCompile(`^bytes (\d+)-(\d+)/(\d+)$`)
+
+type startUploadRequest struct {
+	Name     string `json:"name" validate:"required,max=255"`
+	MimeType string `json:"mimeType" validate:"max=255"`
+	Size     int64  `json:"size" validate:"min=1"`
+	ParentID string `json:"parentId"`
+}
+
+func (h *GoogleDriveHandler) startResumableUpload(w http.ResponseWriter, r *http.Request) utils.Response {
+	var req startUploadRequest
+
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		return utils.DecodeErrorResponse(err)
+	}
+
+	if err := h.policy.CheckSize(req.Size); err != nil {
+		return fileTooLarge(h.policy)
+	}
+	if req.MimeType != "" {
+		if err := h.policy.CheckType(req.MimeType); err != nil {
+			return fileTypeNotAllowed(req.MimeType)
+		}
+	}
+
+	userID := currentUserID(r)
+	client, err := h.getClient(r.Context(), userID)
+	if err != nil {
+		return h.clientErrorResponse(r, err)
+	}
+
+	var parents []string
+	if req.ParentID != "" {
+		parents = []string{req.ParentID}
+	}
+
+	sessionURI, err := googledrive.StartResumableUpload(r.Context(), client, req.Name, req.MimeType, req.Size, parents)
+	if err != nil {
+		if errors.Is(err, googledrive.ErrReauthRequired) {
+			return h.clientErrorResponse(r, err)
+		}
+		log.Printf("Failed to start resumable upload: %v", err)
+		return utils.InternalServerError("Unable to start upload: " + err.Error())
+	}
+
+	session, err := h.sessions.Create(googledrive.UploadSession{
+		UserID:     userID,
+		Name:       req.Name,
+		MimeType:   req.MimeType,
+		Size:       req.Size,
+		SessionURI: sessionURI,
+		HashState:  marshalHash(sha256.New()),
+	})
+	if err != nil {
+		return utils.InternalServerError("Failed to create upload session")
+	}
+
+	return utils.CreatedResponse("Upload session created", session)
+}
+
+// uploadChunk streams one Content-Range chunk straight through to Drive.
+// Every chunk except the last must be a multiple of 256 KiB.
+func (h *GoogleDriveHandler) uploadChunk(w http.ResponseWriter, r *http.Request) utils.Response {
+	defer r.Body.Close()
+
+	id := mux.Vars(r)["id"]
+	userID := currentUserID(r)
+
+	start, end, total, err := parseContentRange(r.Header.Get("Content-Range"))
+	if err != nil {
+		return utils.BadRequestError(err.Error(), nil)
+	}
+
+	session, err := h.sessions.Acquire(id, userID)
+	if err != nil {
+		return sessionErrorResponse(id, err)
+	}
+	defer h.sessions.Release(id)
+
+	length := end - start + 1
+	switch {
+	case session.Status == googledrive.UploadCompleted:
+		return utils.ConflictError("Upload is already complete", session)
+	case total != session.Size:
+		return utils.BadRequestError(fmt.Sprintf("Content-Range total must be %d", session.Size), nil)
+	case start != session.Received:
+		return utils.ConflictError(fmt.Sprintf("Next chunk must start at byte %d", session.Received), session)
+	case end >= total:
+		return utils.BadRequestError("Content-Range end exceeds the upload size", nil)
+	case end+1 < total && length%googledrive.ChunkAlignment != 0:
+		return utils.BadRequestError(fmt.Sprintf("Chunk size must be a multiple of %d bytes", googledrive.ChunkAlignment), nil)
+	case r.ContentLength >= 0 && r.ContentLength != length:
+		return utils.BadRequestError("Content-Length does not match Content-Range", nil)
+	}
+
+	// Chunks can take longer than the server-wide timeouts allow.
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Now().Add(chunkTimeout))
+	rc.SetWriteDeadline(time.Now().Add(chunkTimeout))
+
+	client, err := h.getClient(r.Context(), userID)
+	if err != nil {
+		return h.clientErrorResponse(r, err)
+	}
+
+	var body io.Reader = io.LimitReader(r.Body, length)
+
+	// The declared type is checked when the session starts, the first chunk
+	// tells us what the file really is.
+	if start == 0 {
+		contentType, content, err := storage.DetectContentType(body)
+		if err != nil {
+			return utils.BadRequestError("Unable to read chunk: "+err.Error(), nil)
+		}
+		if err := h.policy.CheckType(contentType); err != nil {
+			if err := googledrive.CancelResumableUpload(r.Context(), client, session.SessionURI); err != nil {
+				log.Printf("Failed to cancel upload session %s: %v", id, err)
+			}
+			h.sessions.Delete(id)
+			return fileTypeNotAllowed(contentType)
+		}
+		h.sessions.SetMimeType(id, contentType)
+		body = content
+	}
+
+	sum := unmarshalHash(session.HashState)
+	if sum != nil && session.HashedBytes == start {
+		body = io.TeeReader(body, sum)
+	} else {
+		sum = nil
+	}
+
+	progress, err := googledrive.UploadChunk(r.Context(), client, session.SessionURI, body, start, length, total)
+	if err != nil {
+		return h.uploadErrorResponse(r, id, err)
+	}
+
+	// The checksum only stays valid when Drive stored the whole chunk.
+	if sum != nil && (progress.Complete || progress.Received == end+1) {
+		h.sessions.SetHashState(id, marshalHash(sum), end+1)
+	} else {
+		h.sessions.SetHashState(id, nil, 0)
+	}
+
+	session, err = h.sessions.Update(id, progress)
+	if err != nil {
+		return sessionErrorResponse(id, err)
+	}
+
+	if session.Status == googledrive.UploadCompleted {
+		return utils.CreatedResponse("Upload complete", session)
+	}
+	return utils.SuccessResponse(fmt.Sprintf("Received %d of %d bytes", session.Received, session.Size), session)
+}
+
+// getUploadStatus syncs the stored progress with Drive before returning it.
+// While a chunk is in flight the stored progress is returned as is, the
+// chunk brings it up to date once Drive answers.
+func (h *GoogleDriveHandler) getUploadStatus(w http.ResponseWriter, r *http.Request) utils.Response {
+	id := mux.Vars(r)["id"]
+	userID := currentUserID(r)
+
+	session, err := h.sessions.Acquire(id, userID)
+	busy := errors.Is(err, googledrive.ErrUploadBusy)
+	if busy {
+		session, err = h.sessions.Get(id, userID)
+	}
+	if err != nil {
+		return sessionErrorResponse(id, err)
+	}
+	if !busy {
+		defer h.sessions.Release(id)
+	}
+
+	if !busy && session.Status == googledrive.UploadActive {
+		client, err := h.getClient(r.Context(), userID)
+		if err != nil {
+			return h.clientErrorResponse(r, err)
+		}
+
+		progress, err := googledrive.QueryUploadStatus(r.Context(), client, session.SessionURI, session.Size)
+		if err != nil {
+			return h.uploadErrorResponse(r, id, err)
+		}
+
+		if session, err = h.sessions.Update(id, progress); err != nil {
+			return sessionErrorResponse(id, err)
+		}
+	}
+
+	return utils.SuccessResponse(fmt.Sprintf("Received %d of %d bytes", session.Received, session.Size), session)
+}
+
+func (h *GoogleDriveHandler) finalizeUpload(w http.ResponseWriter, r *http.Request) utils.Response {
+	id := mux.Vars(r)["id"]
+
+	session, err := h.sessions.Acquire(id, currentUserID(r))
+	if err != nil {
+		return sessionErrorResponse(id, err)
+	}
+	defer h.sessions.Release(id)
+
+	if session.Status != googledrive.UploadCompleted {
+		return utils.ConflictError(fmt.Sprintf("Upload is incomplete, received %d of %d bytes", session.Received, session.Size), session)
+	}
+
+	h.sessions.Delete(id)
+
+	upload := data.Upload{
+		FileID:   session.FileID,
+		Storage:  "gdrive",
+		Name:     session.Name,
+		Size:     session.Size,
+		MimeType: session.MimeType,
+		Uploader: session.UserID,
+		Status:   data.UploadStatusUploaded,
+	}
+	if sum := unmarshalHash(session.HashState); sum != nil && session.HashedBytes == session.Size {
+		upload.SHA256 = hex.EncodeToString(sum.Sum(nil))
+	}
+	h.uploads.record(r.Context(), upload)
+
+	return utils.SuccessResponse("File uploaded successfully", session)
+}
+
+func (h *GoogleDriveHandler) cancelUpload(w http.ResponseWriter, r *http.Request) utils.Response {
+	id := mux.Vars(r)["id"]
+	userID := currentUserID(r)
+
+	session, err := h.sessions.Acquire(id, userID)
+	if err != nil {
+		return sessionErrorResponse(id, err)
+	}
+	defer h.sessions.Release(id)
+
+	if session.Status == googledrive.UploadActive {
+		client, err := h.getClient(r.Context(), userID)
+		if err != nil {
+			return h.clientErrorResponse(r, err)
+		}
+
+		if err := googledrive.CancelResumableUpload(r.Context(), client, session.SessionURI); err != nil {
+			log.Printf("Failed to cancel upload session %s: %v", id, err)
+		}
+	}
+
+	h.sessions.Delete(id)
+	return utils.SuccessResponse(fmt.Sprintf("Upload session %s cancelled", id), nil)
+}
+
+func (h *GoogleDriveHandler) uploadErrorResponse(r *http.Request, id string, err error) utils.Response {
+	switch {
+	case errors.Is(err, googledrive.ErrUploadExpired):
+		h.sessions.Delete(id)
+		return sessionErrorResponse(id, err)
+	case errors.Is(err, googledrive.ErrReauthRequired):
+		return h.clientErrorResponse(r, err)
+	}
+
+	log.Printf("Resumable upload %s failed: %v", id, err)
+	return utils.InternalServerError("Unable to upload chunk: " + err.Error())
+}
+
+func sessionErrorResponse(id string, err error) utils.Response {
+	switch {
+	case errors.Is(err, googledrive.ErrUploadNotFound):
+		return utils.NotFoundError(fmt.Sprintf("Upload session %s not found", id), nil)
+	case errors.Is(err, googledrive.ErrUploadExpired):
+		return utils.NotFoundError(fmt.Sprintf("Upload session %s expired, start a new upload", id), nil)
+	case errors.Is(err, googledrive.ErrUploadBusy):
+		return utils.ConflictError(fmt.Sprintf("Upload session %s is receiving another chunk", id), nil)
+	}
+	return utils.InternalServerError(err.Error())
+}
+
+func marshalHash(h hash.Hash) []byte {
+	state, err := h.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return nil
+	}
+	return state
+}
+
+// unmarshalHash restores a SHA-256 state saved by marshalHash, returning nil
+// when there is none.
+func unmarshalHash(state []byte) hash.Hash {
+	if state == nil {
+		return nil
+	}
+
+	h := sha256.New()
+	if err := h.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+		return nil
+	}
+	return h
+}
+
+func parseContentRange(value string) (start, end, total int64, err error) {
+	match := contentRangePattern.FindStringSubmatch(value)
+	if match == nil {
+		return 0, 0, 0, errors.New("Content-Range must have the form bytes start-end/total")
+	}
+
+	start, _ = strconv.ParseInt(match[1], 10, 64)
+	end, _ = strconv.ParseInt(match[2], 10, 64)
+	total, _ = strconv.ParseInt(match[3], 10, 64)
+
+	if end < start {
+		return 0, 0, 0, errors.New("Content-Range end must not be before start")
+	}
+	return start, end, total, nil
+}
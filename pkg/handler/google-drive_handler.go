@@ -1,163 +1,201 @@
-package handler
-
-import (
-	"context"
-	"encoding/json"
-	"fmt"
-	"log"
-	"net/http"
-	"os"
-	"path/filepath"
-	"time"
-	"web-service/config"
-	googledrive "web-service/pkg/google-drive"
-	"web-service/pkg/kafka"
-	"web-service/pkg/utils"
-
-	"github.com/gorilla/mux"
-	"golang.org/x/oauth2"
-	"google.golang.org/api/drive/v3"
-)
-
-func getAuthURL() string {
-	return googledrive.OauthConfig.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
-}
-
-func handleGoogleDriveAuth(w http.ResponseWriter, r *http.Request) {
-	url := getAuthURL()
-	http.Redirect(w, r, url, http.StatusTemporaryRedirect)
-}
-
-func handleGoogleDriveCallback(w http.ResponseWriter, r *http.Request) utils.Response {
-	code := r.URL.Query().Get("code")
-	if code == "" {
-		return utils.BadRequestError("Code not found in the request", nil)
-	}
-
-	token, err := googledrive.OauthConfig.Exchange(context.Background(), code)
-	if err != nil {
-		log.Printf("Failed to exchange token: %v", err)
-		return utils.BadRequestError("Failed to exchange token", nil)
-	}
-
-	tokenPath := config.Env.GOOGLE_DRIVE_TOKEN_PATH
-	if tokenPath == "" {
-		log.Fatal("Missing GOOGLE_DRIVE_TOKEN_PATH environment variable")
-	}
-
-	if err := saveToken(token, tokenPath); err != nil {
-		log.Printf("Failed to save token: %v", err)
-		return utils.InternalServerError("Failed to save token: " + err.Error())
-	}
-
-	return utils.CreatedResponse("Token successfully saved", nil)
-}
-
-func saveToken(token *oauth2.Token, tokenPath string) error {
-	dir := filepath.Dir(tokenPath)
-	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("unable to create directory for token file: %v", err)
-		}
-	}
-
-	f, err := os.Create(tokenPath)
-	if err != nil {
-		return fmt.Errorf("unable to create token file: %v", err)
-	}
-	defer f.Close()
-
-	// Ghi token vào file dưới dạng JSON
-	if err := json.NewEncoder(f).Encode(token); err != nil {
-		return fmt.Errorf("unable to write token to file: %v", err)
-	}
-
-	log.Printf("Token saved to file: %s", tokenPath)
-	return nil
-}
-
-func getClient() *http.Client {
-	tokenPath := config.Env.GOOGLE_DRIVE_TOKEN_PATH
-	if tokenPath == "" {
-		log.Fatal("Missing GOOGLE_DRIVE_TOKEN_PATH environment variable")
-	}
-
-	f, err := os.Open(tokenPath)
-	if err != nil {
-		log.Fatalf("Unable to open token file: %v", err)
-	}
-	defer f.Close()
-
-	token := &oauth2.Token{}
-	if err := json.NewDecoder(f).Decode(token); err != nil {
-		log.Fatalf("Unable to decode token: %v", err)
-	}
-
-	return googledrive.OauthConfig.Client(context.Background(), token)
-}
-
-func handleGoogleDriveUpload(w http.ResponseWriter, r *http.Request) utils.Response {
-	client := getClient()
-	srv, err := drive.New(client)
-	if err != nil {
-		return utils.InternalServerError("Unable to create Drive client: " + err.Error())
-	}
-
-	file, header, err := r.FormFile("file")
-	if err != nil {
-		return utils.BadRequestError("Unable to create Drive client"+err.Error(), nil)
-	}
-	defer file.Close()
-
-	driveFile := &drive.File{Name: header.Filename}
-	_, err = srv.Files.Create(driveFile).Media(file).Do()
-	if err != nil {
-		return utils.InternalServerError("Unable to upload file: " + err.Error())
-	}
-
-	go func() {
-		kafka.Produce("file_uploaded", []string{
-			fmt.Sprintf("File '%s' uploaded successfully", header.Filename),
-		})
-	}()
-
-	return utils.SuccessResponse("File uploaded successfully", nil)
-}
-
-func getFileUploadEvent(w http.ResponseWriter, r *http.Request) utils.Response {
-	message := kafka.Consume([]string{"file_uploaded"}, 10, 5*time.Second)
-
-	if message == nil {
-		return utils.NotFoundError("No file uploaded event found", nil)
-	}
-
-	res := struct {
-		Key       string `json:"key"`
-		Value     string `json:"value"`
-		Topic     string `json:"topic"`
-		Timestamp string `json:"timestamp"`
-	}{
-		Key:       string(message.Key),
-		Value:     string(message.Value),
-		Topic:     *message.TopicPartition.Topic,
-		Timestamp: message.Timestamp.String(),
-	}
-
-	resJSON, err := json.Marshal(res)
-
-	if err != nil {
-		return utils.InternalServerError("Failed to marshal response: " + err.Error())
-	}
-
-	return utils.SuccessResponse("File uploaded event", json.RawMessage(resJSON))
-}
-
-func GoogleDriveRoutes(r *mux.Router) {
-	googleDriveRouter := r.PathPrefix("/googleDrives").Subrouter()
-
-	// Google Drive routes
-	googleDriveRouter.HandleFunc("/auth/google", handleGoogleDriveAuth).Methods(http.MethodGet)
-	googleDriveRouter.HandleFunc("/auth/google/callback", utils.WrapHandler(handleGoogleDriveCallback)).Methods(http.MethodGet)
-	googleDriveRouter.HandleFunc("/upload", utils.WrapHandler(handleGoogleDriveUpload)).Methods(http.MethodPost)
-	googleDriveRouter.HandleFunc("/upload/get-event", utils.WrapHandler(getFileUploadEvent)).Methods(http.MethodGet)
-}
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+	"web-service/pkg/data"
+	"web-service/pkg/events"
+	googledrive "web-service/pkg/google-drive"
+	"web-service/pkg/kafka"
+	"web-service/pkg/middlewares"
+	"web-service/pkg/storage"
+	"web-service/pkg/utils"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/oauth2"
+)
+
+type GoogleDriveHandler struct {
+	tokens   *googledrive.TokenSources
+	states   *googledrive.StateStore
+	sessions *googledrive.UploadSessionStore
+	uploads  *UploadHandler
+	policy   storage.Policy
+}
+
+func NewGoogleDriveHandler(tokens *googledrive.TokenSources, uploads data.UploadRepository, policy storage.Policy) *GoogleDriveHandler {
+	h := &GoogleDriveHandler{
+		tokens:   tokens,
+		states:   googledrive.NewStateStore(),
+		sessions: googledrive.NewUploadSessionStore(),
+		uploads:  NewUploadHandler(storage.DriveProvider(tokens), "gdrive", uploads, policy),
+		policy:   policy,
+	}
+	h.uploads.errorResponse = h.storageErrorResponse
+
+	return h
+}
+
+func currentUserID(r *http.Request) string {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		return ""
+	}
+	return principal.Subject
+}
+
+func (h *GoogleDriveHandler) authURL(userID string) (string, error) {
+	state, err := h.states.New(userID)
+	if err != nil {
+		return "", err
+	}
+
+	return googledrive.OauthConfig.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.ApprovalForce), nil
+}
+
+func (h *GoogleDriveHandler) handleGoogleDriveAuth(w http.ResponseWriter, r *http.Request) utils.Response {
+	url, err := h.authURL(currentUserID(r))
+	if err != nil {
+		return utils.InternalServerError("Failed to create OAuth state")
+	}
+
+	return utils.SuccessResponse("Open authUrl to authorize Google Drive", map[string]string{"authUrl": url})
+}
+
+// reauthResponse asks the caller to authorize Google Drive again, including
+// a fresh authorization URL.
+func (h *GoogleDriveHandler) reauthResponse(r *http.Request, message string) utils.Response {
+	response := utils.UnauthorizedError(message)
+
+	if url, err := h.authURL(currentUserID(r)); err == nil {
+		response.Data = map[string]string{"authUrl": url}
+	}
+	return response
+}
+
+func (h *GoogleDriveHandler) handleGoogleDriveCallback(w http.ResponseWriter, r *http.Request) utils.Response {
+	userID, ok := h.states.Consume(r.URL.Query().Get("state"))
+	if !ok {
+		return utils.BadRequestError("Invalid or expired OAuth state", nil)
+	}
+
+	code := r.URL.Query().Get("code")
+	if code == "" {
+		return utils.BadRequestError("Code not found in the request", nil)
+	}
+
+	token, err := googledrive.OauthConfig.Exchange(r.Context(), code)
+	if err != nil {
+		log.Printf("Failed to exchange token: %v", err)
+		return utils.BadRequestError("Failed to exchange token", nil)
+	}
+
+	if err := h.tokens.Save(r.Context(), userID, token); err != nil {
+		log.Printf("Failed to save token: %v", err)
+		return utils.InternalServerError("Failed to save token: " + err.Error())
+	}
+
+	return utils.CreatedResponse("Token successfully saved", nil)
+}
+
+func (h *GoogleDriveHandler) getClient(ctx context.Context, userID string) (*http.Client, error) {
+	return h.tokens.Client(ctx, userID)
+}
+
+func driveUnavailable(w http.ResponseWriter, r *http.Request) utils.Response {
+	return utils.ServiceUnavailableError("Google Drive is not configured on this server")
+}
+
+func (h *GoogleDriveHandler) clientErrorResponse(r *http.Request, err error) utils.Response {
+	switch {
+	case errors.Is(err, googledrive.ErrNotConfigured):
+		return driveUnavailable(nil, r)
+	case errors.Is(err, googledrive.ErrTokenNotFound):
+		return h.reauthResponse(r, "Google Drive is not authorized for this user")
+	case errors.Is(err, googledrive.ErrReauthRequired):
+		return h.reauthResponse(r, "Google Drive authorization was revoked, please authorize again")
+	}
+
+	log.Printf("Failed to load Google Drive token: %v", err)
+	return utils.InternalServerError("Unable to load Google Drive token")
+}
+
+// storageErrorResponse adds a fresh authorization URL to Drive auth errors.
+func (h *GoogleDriveHandler) storageErrorResponse(r *http.Request, err error) utils.Response {
+	if errors.Is(err, googledrive.ErrTokenNotFound) || errors.Is(err, googledrive.ErrReauthRequired) {
+		return h.clientErrorResponse(r, err)
+	}
+	return storageErrorResponse(r, err)
+}
+
+func (h *GoogleDriveHandler) handleGoogleDriveUpload(w http.ResponseWriter, r *http.Request) utils.Response {
+	return h.uploads.upload(w, r)
+}
+
+type fileUploadEventResponse struct {
+	events.Envelope
+	Data      events.FileUploaded `json:"data"`
+	Topic     string              `json:"topic"`
+	Partition int32               `json:"partition"`
+	Offset    int64               `json:"offset"`
+}
+
+func getFileUploadEvent(w http.ResponseWriter, r *http.Request) utils.Response {
+	message := kafka.Consume([]string{events.TopicFileUploaded}, 10, 5*time.Second)
+
+	if message == nil {
+		return utils.NotFoundError("No file uploaded event found", nil)
+	}
+
+	event, err := events.Decode(message)
+	if err != nil {
+		log.Printf("Skipping undecodable event at %s[%d]@%d: %v", message.Topic, message.Partition, message.Offset, err)
+		return utils.InternalServerError("Failed to decode event: " + err.Error())
+	}
+	if event.Type != events.TypeFileUploaded {
+		return utils.InternalServerError("Unexpected event type " + event.Type)
+	}
+
+	res := fileUploadEventResponse{
+		Envelope:  event,
+		Topic:     message.Topic,
+		Partition: message.Partition,
+		Offset:    message.Offset,
+	}
+	if err := event.DecodeData(&res.Data); err != nil {
+		return utils.InternalServerError(err.Error())
+	}
+
+	return utils.SuccessResponse("File uploaded event", res)
+}
+
+func GoogleDriveRoutes(r *mux.Router, tokens *googledrive.TokenSources, uploads data.UploadRepository, policy storage.Policy) {
+	googleDriveRouter := r.PathPrefix("/googleDrives").Subrouter()
+
+	if !googledrive.Enabled() {
+		googleDriveRouter.PathPrefix("/").HandlerFunc(utils.WrapHandler(driveUnavailable))
+		return
+	}
+
+	h := NewGoogleDriveHandler(tokens, uploads, policy)
+
+	// Google Drive routes
+	googleDriveRouter.HandleFunc("/auth/google", middlewares.RequireRoles(utils.WrapHandler(h.handleGoogleDriveAuth), "drive:write")).Methods(http.MethodGet)
+	googleDriveRouter.HandleFunc("/auth/google/callback", utils.WrapHandler(h.handleGoogleDriveCallback)).Methods(http.MethodGet)
+	googleDriveRouter.HandleFunc("/upload", middlewares.RequireRoles(utils.WrapHandler(h.handleGoogleDriveUpload), "drive:write")).Methods(http.MethodPost)
+	googleDriveRouter.HandleFunc("/upload/resumable", middlewares.RequireRoles(utils.WrapHandler(h.startResumableUpload), "drive:write")).Methods(http.MethodPost)
+	googleDriveRouter.HandleFunc("/upload/resumable/{id}", middlewares.RequireRoles(utils.WrapHandler(h.uploadChunk), "drive:write")).Methods(http.MethodPut)
+	googleDriveRouter.HandleFunc("/upload/resumable/{id}", middlewares.RequireRoles(utils.WrapHandler(h.getUploadStatus), "drive:write")).Methods(http.MethodGet)
+	googleDriveRouter.HandleFunc("/upload/resumable/{id}/complete", middlewares.RequireRoles(utils.WrapHandler(h.finalizeUpload), "drive:write")).Methods(http.MethodPost)
+	googleDriveRouter.HandleFunc("/upload/resumable/{id}", middlewares.RequireRoles(utils.WrapHandler(h.cancelUpload), "drive:write")).Methods(http.MethodDelete)
+	googleDriveRouter.HandleFunc("/files", middlewares.RequireRoles(utils.WrapHandler(h.listFiles), "drive:read")).Methods(http.MethodGet)
+	googleDriveRouter.HandleFunc("/files/{id}", middlewares.RequireRoles(utils.WrapHandler(h.getFile), "drive:read")).Methods(http.MethodGet)
+	googleDriveRouter.HandleFunc("/files/{id}/download", middlewares.RequireRoles(h.downloadFile, "drive:read")).Methods(http.MethodGet)
+	googleDriveRouter.HandleFunc("/files/{id}", middlewares.RequireRoles(utils.WrapHandler(h.updateFile), "drive:write")).Methods(http.MethodPatch)
+	googleDriveRouter.HandleFunc("/files/{id}", middlewares.RequireRoles(utils.WrapHandler(h.deleteFile), "drive:write")).Methods(http.MethodDelete)
+	googleDriveRouter.HandleFunc("/folders", middlewares.RequireRoles(utils.WrapHandler(h.createFolder), "drive:write")).Methods(http.MethodPost)
+	googleDriveRouter.HandleFunc("/upload/get-event", middlewares.RequireRoles(utils.WrapHandler(getFileUploadEvent), "drive:read")).Methods(http.MethodGet)
+}
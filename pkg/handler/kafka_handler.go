@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+	"web-service/pkg/kafka"
+	"web-service/pkg/middlewares"
+	"web-service/pkg/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// kafkaAdminTimeout bounds the cluster requests of an admin call.
+const kafkaAdminTimeout = 10 * time.Second
+
+type KafkaHandler struct {
+	admin kafka.Admin
+}
+
+func NewKafkaHandler(admin kafka.Admin) *KafkaHandler {
+	return &KafkaHandler{admin: admin}
+}
+
+func (h *KafkaHandler) getDeliveries(w http.ResponseWriter, r *http.Request) utils.Response {
+	return utils.SuccessResponse("Get Kafka delivery metrics successfully", kafka.Deliveries())
+}
+
+func (h *KafkaHandler) listTopics(w http.ResponseWriter, r *http.Request) utils.Response {
+	ctx, cancel := context.WithTimeout(r.Context(), kafkaAdminTimeout)
+	defer cancel()
+
+	topics, err := h.admin.Topics(ctx)
+	if err != nil {
+		return kafkaAdminError("Failed to get Kafka topics", err)
+	}
+
+	return utils.SuccessResponse("Get Kafka topics successfully", topics)
+}
+
+func (h *KafkaHandler) getTopic(w http.ResponseWriter, r *http.Request) utils.Response {
+	name := mux.Vars(r)["name"]
+
+	ctx, cancel := context.WithTimeout(r.Context(), kafkaAdminTimeout)
+	defer cancel()
+
+	topic, err := h.admin.Topic(ctx, name)
+	if errors.Is(err, kafka.ErrTopicNotFound) {
+		return utils.NotFoundError(fmt.Sprintf("Kafka topic %s not found", name), nil)
+	}
+	if err != nil {
+		return kafkaAdminError("Failed to get Kafka topic "+name, err)
+	}
+
+	return utils.SuccessResponse("Get Kafka topic successfully", topic)
+}
+
+func (h *KafkaHandler) listGroups(w http.ResponseWriter, r *http.Request) utils.Response {
+	ctx, cancel := context.WithTimeout(r.Context(), kafkaAdminTimeout)
+	defer cancel()
+
+	groups, err := h.admin.Groups(ctx)
+	if err != nil {
+		return kafkaAdminError("Failed to get Kafka consumer groups", err)
+	}
+
+	return utils.SuccessResponse("Get Kafka consumer groups successfully", groups)
+}
+
+// getGroupOffsets returns the committed offsets and lag of a consumer group.
+func (h *KafkaHandler) getGroupOffsets(w http.ResponseWriter, r *http.Request) utils.Response {
+	group := mux.Vars(r)["group"]
+
+	ctx, cancel := context.WithTimeout(r.Context(), kafkaAdminTimeout)
+	defer cancel()
+
+	offsets, err := h.admin.GroupOffsets(ctx, group)
+	if errors.Is(err, kafka.ErrGroupNotFound) {
+		return utils.NotFoundError(fmt.Sprintf("Kafka consumer group %s not found", group), nil)
+	}
+	if err != nil {
+		return kafkaAdminError("Failed to get offsets of Kafka consumer group "+group, err)
+	}
+
+	return utils.SuccessResponse("Get Kafka consumer group offsets successfully", offsets)
+}
+
+func kafkaAdminError(message string, err error) utils.Response {
+	log.Printf("%s: %v", message, err)
+	return utils.ServiceUnavailableError(message + ": " + err.Error())
+}
+
+func KafkaRoutes(r *mux.Router, admin kafka.Admin) {
+	h := NewKafkaHandler(admin)
+	kafkaRouter := r.PathPrefix("/admin/kafka").Subrouter()
+
+	kafkaRouter.HandleFunc("/metrics", middlewares.RequireRoles(utils.WrapHandler(h.getDeliveries), "admin")).Methods(http.MethodGet)
+	kafkaRouter.HandleFunc("/topics", middlewares.RequireRoles(utils.WrapHandler(h.listTopics), "admin")).Methods(http.MethodGet)
+	kafkaRouter.HandleFunc("/topics/{name}", middlewares.RequireRoles(utils.WrapHandler(h.getTopic), "admin")).Methods(http.MethodGet)
+	kafkaRouter.HandleFunc("/groups", middlewares.RequireRoles(utils.WrapHandler(h.listGroups), "admin")).Methods(http.MethodGet)
+	kafkaRouter.HandleFunc("/groups/{group}", middlewares.RequireRoles(utils.WrapHandler(h.getGroupOffsets), "admin")).Methods(http.MethodGet)
+}
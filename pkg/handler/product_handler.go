@@ -1,89 +1,260 @@
-package handler
-
-import (
-	"encoding/json"
-	"fmt"
-	"net/http"
-	"web-service/pkg/data"
-	"web-service/pkg/utils"
-
-	"github.com/gorilla/mux"
-)
-
-func getProducts(w http.ResponseWriter, r *http.Request) utils.Response {
-
-	return utils.SuccessResponse("Get all products successfully", data.ListProduct)
-}
-
-func getProductById(w http.ResponseWriter, r *http.Request) utils.Response {
-	vars := mux.Vars(r)
-	id, err := utils.GetId(vars["id"])
-
-	if err != nil {
-		return utils.BadRequestError("Invalid id", nil)
-	}
-
-	for _, product := range data.ListProduct {
-		if product.ID == id {
-			return utils.SuccessResponse(fmt.Sprintf("Get product with id %d successfully", id), product)
-		}
-	}
-
-	return utils.NotFoundError(fmt.Sprintf("Product with id %d not found", id), nil)
-}
-
-func createProduct(w http.ResponseWriter, r *http.Request) utils.Response {
-	defer r.Body.Close()
-
-	var product data.ProductData
-
-	decode := json.NewDecoder(r.Body)
-	decode.DisallowUnknownFields()
-
-	if err := decode.Decode(&product); err != nil {
-		errorMessage := utils.JSONDecodeError(err)
-
-		return utils.BadRequestError(errorMessage, nil)
-	}
-
-	data.ListProduct = append(data.ListProduct, product)
-
-	return utils.CreatedResponse("Create product successfully", product)
-}
-
-func deleteProduct(w http.ResponseWriter, r *http.Request) utils.Response {
-	vars := mux.Vars(r)
-	id, err := utils.GetId(vars["id"])
-
-	if err != nil {
-		utils.BadRequestError("Invalid id", nil)
-	}
-
-	var result []data.ProductData
-	var found bool = false
-
-	for _, product := range data.ListProduct {
-		if product.ID != id {
-			result = append(result, product)
-		} else {
-			found = true
-		}
-	}
-
-	if !found {
-		return utils.NotFoundError(fmt.Sprintf("Product with id %d not found", id), nil)
-	}
-
-	data.ListProduct = result
-
-	return utils.SuccessResponse(fmt.Sprintf("Delete product with id %d successfully", id), nil)
-}
-
-func ProductRoutes(r *mux.Router) {
-	productRouter := r.PathPrefix("/products").Subrouter().StrictSlash(true)
-
-	productRouter.HandleFunc("/", utils.WrapHandler(getProducts)).Methods(http.MethodGet).Name("getProducts")
-	productRouter.HandleFunc("/{id:[0-9]+}", utils.WrapHandler(getProductById)).Methods(http.MethodGet).Name("getProductById")
-	productRouter.HandleFunc("/create", utils.WrapHandler(createProduct)).Methods(http.MethodPost).Name("createProduct")
-	productRouter.HandleFunc("/{id:[0-9]+}", utils.WrapHandler(deleteProduct)).Methods(http.MethodDelete).Name("deleteProduct")
-}
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"web-service/pkg/data"
+	"web-service/pkg/events"
+	"web-service/pkg/middlewares"
+	"web-service/pkg/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type ProductHandler struct {
+	repo data.ProductRepository
+}
+
+func NewProductHandler(repo data.ProductRepository) *ProductHandler {
+	return &ProductHandler{repo: repo}
+}
+
+func (h *ProductHandler) getProducts(w http.ResponseWriter, r *http.Request) utils.Response {
+	query := r.URL.Query()
+
+	page, err := utils.ParsePage(query)
+	if err != nil {
+		return utils.BadRequestError("Invalid query: "+err.Error(), nil)
+	}
+
+	sortFields, err := utils.ParseSort(query.Get("sort"), data.ProductSortFields...)
+	if err != nil {
+		return utils.BadRequestError("Invalid query: "+err.Error(), nil)
+	}
+
+	result, err := h.repo.List(r.Context(), data.ProductQuery{
+		Name:        query.Get("name"),
+		Description: query.Get("description"),
+		Sort:        sortFields,
+		Page:        page,
+	})
+	if err != nil {
+		log.Printf("Failed to list products: %v", err)
+		return utils.InternalServerError("Failed to get products")
+	}
+
+	pagination := utils.NewPagination(page, result.Total)
+	utils.SetLinkHeader(w, r, pagination)
+
+	return utils.PaginatedResponse("Get all products successfully", result.Items, pagination)
+}
+
+func (h *ProductHandler) getProductById(w http.ResponseWriter, r *http.Request) utils.Response {
+	vars := mux.Vars(r)
+	id, err := utils.GetId(vars["id"])
+
+	if err != nil {
+		return utils.BadRequestError("Invalid id", nil)
+	}
+
+	product, err := h.repo.GetByID(r.Context(), id)
+	if errors.Is(err, data.ErrProductNotFound) {
+		return utils.NotFoundError(fmt.Sprintf("Product with id %d not found", id), nil)
+	}
+	if err != nil {
+		log.Printf("Failed to get product %d: %v", id, err)
+		return utils.InternalServerError("Failed to get product")
+	}
+
+	return utils.SuccessResponse(fmt.Sprintf("Get product with id %d successfully", id), product)
+}
+
+func (h *ProductHandler) createProduct(w http.ResponseWriter, r *http.Request) utils.Response {
+	var product data.ProductData
+
+	if err := utils.DecodeJSON(w, r, &product); err != nil {
+		return utils.DecodeErrorResponse(err)
+	}
+
+	// IDs are assigned by the server, one sent by the client is ignored.
+	product.ID = 0
+
+	created, err := h.repo.Create(r.Context(), product, productOutbox(r, events.TypeProductCreated))
+	if errors.Is(err, data.ErrProductExists) {
+		return utils.ConflictError("Product id is already taken, retry the request", nil)
+	}
+	if err != nil {
+		log.Printf("Failed to create product: %v", err)
+		return utils.InternalServerError("Failed to create product")
+	}
+
+	return utils.CreatedResponse("Create product successfully", created)
+}
+
+func (h *ProductHandler) updateProduct(w http.ResponseWriter, r *http.Request) utils.Response {
+	vars := mux.Vars(r)
+	id, err := utils.GetId(vars["id"])
+
+	if err != nil {
+		return utils.BadRequestError("Invalid id", nil)
+	}
+
+	var product data.ProductData
+
+	if err := utils.DecodeJSON(w, r, &product); err != nil {
+		return utils.DecodeErrorResponse(err)
+	}
+
+	if product.ID != 0 && product.ID != id {
+		return utils.BadRequestError("Product id cannot be changed", nil)
+	}
+	product.ID = id
+
+	return h.saveProduct(r, product)
+}
+
+func (h *ProductHandler) patchProduct(w http.ResponseWriter, r *http.Request) utils.Response {
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if contentType != "application/merge-patch+json" && contentType != "application/json" {
+		return utils.UnsupportedMediaTypeError("Content-Type must be application/merge-patch+json")
+	}
+
+	vars := mux.Vars(r)
+	id, err := utils.GetId(vars["id"])
+
+	if err != nil {
+		return utils.BadRequestError("Invalid id", nil)
+	}
+
+	patch, err := utils.ReadBody(w, r)
+	if err != nil {
+		return utils.DecodeErrorResponse(err)
+	}
+
+	existing, err := h.repo.GetByID(r.Context(), id)
+	if errors.Is(err, data.ErrProductNotFound) {
+		return utils.NotFoundError(fmt.Sprintf("Product with id %d not found", id), nil)
+	}
+	if err != nil {
+		log.Printf("Failed to get product %d: %v", id, err)
+		return utils.InternalServerError("Failed to get product")
+	}
+
+	original, err := json.Marshal(existing)
+	if err != nil {
+		return utils.InternalServerError("Failed to encode product")
+	}
+
+	merged, err := utils.MergePatch(original, patch)
+	if err != nil {
+		return utils.BadRequestError(utils.JSONDecodeError(err), nil)
+	}
+
+	var product data.ProductData
+
+	if err := utils.DecodeJSONBytes(merged, &product); err != nil {
+		return utils.DecodeErrorResponse(err)
+	}
+
+	if product.ID != id {
+		return utils.BadRequestError("Product id cannot be changed", nil)
+	}
+
+	return h.saveProduct(r, product)
+}
+
+func (h *ProductHandler) saveProduct(r *http.Request, product data.ProductData) utils.Response {
+	_, err := h.repo.Update(r.Context(), product, productOutbox(r, events.TypeProductUpdated))
+	if errors.Is(err, data.ErrProductNotFound) {
+		return utils.NotFoundError(fmt.Sprintf("Product with id %d not found", product.ID), nil)
+	}
+	if err != nil {
+		log.Printf("Failed to update product %d: %v", product.ID, err)
+		return utils.InternalServerError("Failed to update product")
+	}
+
+	return utils.SuccessResponse(fmt.Sprintf("Update product with id %d successfully", product.ID), product)
+}
+
+func (h *ProductHandler) deleteProduct(w http.ResponseWriter, r *http.Request) utils.Response {
+	vars := mux.Vars(r)
+	id, err := utils.GetId(vars["id"])
+
+	if err != nil {
+		return utils.BadRequestError("Invalid id", nil)
+	}
+
+	_, err = h.repo.Delete(r.Context(), id, productOutbox(r, events.TypeProductDeleted))
+	if errors.Is(err, data.ErrProductNotFound) {
+		return utils.NotFoundError(fmt.Sprintf("Product with id %d not found", id), nil)
+	}
+	if err != nil {
+		log.Printf("Failed to delete product %d: %v", id, err)
+		return utils.InternalServerError("Failed to delete product")
+	}
+
+	return utils.SuccessResponse(fmt.Sprintf("Delete product with id %d successfully", id), nil)
+}
+
+// productOutbox queues a product event with the change, keyed by product ID.
+// The outbox relays the messages of a key one after the other, retries
+// included, and Kafka keeps them in one partition, so consumers get the
+// events of a product in order.
+func productOutbox(r *http.Request, eventType string) data.ProductOutbox {
+	userID := currentUserID(r)
+
+	return func(before, after *data.ProductData) ([]data.OutboxMessage, error) {
+		id := productID(before, after)
+
+		e, err := events.NewProductChanged(eventType, userID, id, productSnapshot(before), productSnapshot(after))
+		if err != nil {
+			return nil, err
+		}
+
+		message, err := events.Encode(events.TopicProductEvents, e)
+		if err != nil {
+			return nil, err
+		}
+		message.Key = []byte(strconv.Itoa(id))
+
+		return []data.OutboxMessage{
+			data.NewOutboxMessage(message.Topic, message.Key, message.Value, message.Headers),
+		}, nil
+	}
+}
+
+func productID(before, after *data.ProductData) int {
+	if after != nil {
+		return after.ID
+	}
+	return before.ID
+}
+
+func productSnapshot(product *data.ProductData) *events.Product {
+	if product == nil {
+		return nil
+	}
+	return &events.Product{
+		ID:          product.ID,
+		Name:        product.Name,
+		Description: product.Description,
+	}
+}
+
+func ProductRoutes(r *mux.Router, repo data.ProductRepository) {
+	h := NewProductHandler(repo)
+	productRouter := r.PathPrefix("/products").Subrouter().StrictSlash(true)
+
+	productRouter.HandleFunc("", middlewares.RequireRoles(utils.WrapHandler(h.createProduct), "products:write")).Methods(http.MethodPost).Name("createProduct")
+	productRouter.HandleFunc("/", utils.WrapHandler(h.getProducts)).Methods(http.MethodGet).Name("getProducts")
+	productRouter.HandleFunc("/{id:[0-9]+}", utils.WrapHandler(h.getProductById)).Methods(http.MethodGet).Name("getProductById")
+	productRouter.HandleFunc("/create", middlewares.RequireRoles(utils.WrapHandler(h.createProduct), "products:write")).Methods(http.MethodPost).Name("createProductLegacy")
+	productRouter.HandleFunc("/{id:[0-9]+}", middlewares.RequireRoles(utils.WrapHandler(h.updateProduct), "products:write")).Methods(http.MethodPut).Name("updateProduct")
+	productRouter.HandleFunc("/{id:[0-9]+}", middlewares.RequireRoles(utils.WrapHandler(h.patchProduct), "products:write")).Methods(http.MethodPatch).Name("patchProduct")
+	productRouter.HandleFunc("/{id:[0-9]+}", middlewares.RequireRoles(utils.WrapHandler(h.deleteProduct), "products:write")).Methods(http.MethodDelete).Name("deleteProduct")
+}
@@ -0,0 +1,316 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"web-service/pkg/data"
+	"web-service/pkg/events"
+	"web-service/pkg/kafka"
+	"web-service/pkg/middlewares"
+
+	"github.com/gorilla/mux"
+)
+
+const testUserID = "user-1"
+
+func TestProductEvents(t *testing.T) {
+	seed := data.DefaultProducts()
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		wantType    string
+		wantID      int
+		wantBefore  *events.Product
+		wantAfter   *events.Product
+	}{
+		{
+			name:      "create",
+			method:    http.MethodPost,
+			path:      "/products",
+			body:      `{"name": "Lamp", "description": "A desk lamp"}`,
+			wantType:  events.TypeProductCreated,
+			wantID:    4,
+			wantAfter: &events.Product{ID: 4, Name: "Lamp", Description: "A desk lamp"},
+		},
+		{
+			name:      "create ignores client id",
+			method:    http.MethodPost,
+			path:      "/products/create",
+			body:      `{"id": 1, "name": "Lamp"}`,
+			wantType:  events.TypeProductCreated,
+			wantID:    4,
+			wantAfter: &events.Product{ID: 4, Name: "Lamp"},
+		},
+		{
+			name:       "replace",
+			method:     http.MethodPut,
+			path:       "/products/1",
+			body:       `{"name": "Renamed", "description": "Replaced"}`,
+			wantType:   events.TypeProductUpdated,
+			wantID:     1,
+			wantBefore: productSnapshot(&seed[0]),
+			wantAfter:  &events.Product{ID: 1, Name: "Renamed", Description: "Replaced"},
+		},
+		{
+			name:        "patch",
+			method:      http.MethodPatch,
+			path:        "/products/2",
+			contentType: "application/merge-patch+json",
+			body:        `{"description": "Patched"}`,
+			wantType:    events.TypeProductUpdated,
+			wantID:      2,
+			wantBefore:  productSnapshot(&seed[1]),
+			wantAfter:   &events.Product{ID: 2, Name: seed[1].Name, Description: "Patched"},
+		},
+		{
+			name:       "delete",
+			method:     http.MethodDelete,
+			path:       "/products/3",
+			wantType:   events.TypeProductDeleted,
+			wantID:     3,
+			wantBefore: productSnapshot(&seed[2]),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox := data.NewMemoryOutboxRepository()
+			router := productRouter(data.NewMemoryProductRepository(data.DefaultProducts(), outbox))
+
+			rec := serve(router, tt.method, tt.path, tt.contentType, tt.body)
+			if rec.Code >= 300 {
+				t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+			}
+
+			queued := drainOutbox(t, outbox)
+			if len(queued) != 1 {
+				t.Fatalf("queued %d events, want 1", len(queued))
+			}
+
+			e, change := decodeProductEvent(t, queued[0])
+			if e.Type != tt.wantType {
+				t.Errorf("type = %q, want %q", e.Type, tt.wantType)
+			}
+			if e.UserID != testUserID {
+				t.Errorf("userId = %q, want %q", e.UserID, testUserID)
+			}
+			if !reflect.DeepEqual(change.Before, tt.wantBefore) {
+				t.Errorf("before = %+v, want %+v", change.Before, tt.wantBefore)
+			}
+			if !reflect.DeepEqual(change.After, tt.wantAfter) {
+				t.Errorf("after = %+v, want %+v", change.After, tt.wantAfter)
+			}
+
+			if change.ProductID != tt.wantID {
+				t.Errorf("productId = %d, want %d", change.ProductID, tt.wantID)
+			}
+			if queued[0].Topic != events.TopicProductEvents || string(queued[0].Key) != strconv.Itoa(tt.wantID) {
+				t.Errorf("topic = %q, key = %q", queued[0].Topic, queued[0].Key)
+			}
+		})
+	}
+}
+
+func TestProductEventsStayInOrderOnRetry(t *testing.T) {
+	outbox := data.NewMemoryOutboxRepository()
+	router := productRouter(data.NewMemoryProductRepository(data.DefaultProducts(), outbox))
+
+	if rec := serve(router, http.MethodPost, "/products", "", `{"name": "Lamp"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("create: status = %d, body = %s", rec.Code, rec.Body)
+	}
+	if rec := serve(router, http.MethodDelete, "/products/4", "", ""); rec.Code != http.StatusOK {
+		t.Fatalf("delete: status = %d, body = %s", rec.Code, rec.Body)
+	}
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	created, err := outbox.Claim(ctx, now, time.Minute)
+	if err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+	if err := outbox.MarkFailed(ctx, created.ID, "broker down", now.Add(time.Second)); err != nil {
+		t.Fatalf("MarkFailed: %v", err)
+	}
+
+	// The deletion must not overtake the creation being retried.
+	if message, err := outbox.Claim(ctx, now, time.Minute); !errors.Is(err, data.ErrOutboxEmpty) {
+		t.Fatalf("Claim while the creation is retried: claimed %s, err = %v", message.ID, err)
+	}
+
+	var types []string
+	for _, msg := range drainOutboxAt(t, outbox, now.Add(time.Second)) {
+		e, _ := decodeProductEvent(t, msg)
+		types = append(types, e.Type)
+	}
+	if want := []string{events.TypeProductCreated, events.TypeProductDeleted}; !reflect.DeepEqual(types, want) {
+		t.Fatalf("relayed %v, want %v", types, want)
+	}
+}
+
+func TestProductEventsNotQueuedOnFailure(t *testing.T) {
+	tests := []struct {
+		name        string
+		repo        func(outbox *data.MemoryOutboxRepository) data.ProductRepository
+		method      string
+		path        string
+		contentType string
+		body        string
+		wantStatus  int
+	}{
+		{name: "create with taken id", repo: conflicting, method: http.MethodPost, path: "/products", body: `{"name": "Lamp"}`, wantStatus: http.StatusConflict},
+		{name: "create without name", method: http.MethodPost, path: "/products", body: `{"description": "Nameless"}`, wantStatus: http.StatusUnprocessableEntity},
+		{name: "replace changing id", method: http.MethodPut, path: "/products/1", body: `{"id": 2, "name": "Renamed"}`, wantStatus: http.StatusBadRequest},
+		{name: "replace missing", method: http.MethodPut, path: "/products/99", body: `{"name": "Renamed"}`, wantStatus: http.StatusNotFound},
+		{name: "patch changing id", method: http.MethodPatch, path: "/products/1", body: `{"id": 2}`, wantStatus: http.StatusBadRequest},
+		{name: "patch missing", method: http.MethodPatch, path: "/products/99", body: `{"name": "Renamed"}`, wantStatus: http.StatusNotFound},
+		{name: "patch wrong content type", method: http.MethodPatch, path: "/products/1", contentType: "text/plain", body: `{"name": "Renamed"}`, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "delete missing", method: http.MethodDelete, path: "/products/99", wantStatus: http.StatusNotFound},
+		{name: "create failing", repo: failing, method: http.MethodPost, path: "/products", body: `{"name": "Lamp"}`, wantStatus: http.StatusInternalServerError},
+		{name: "replace failing", repo: failing, method: http.MethodPut, path: "/products/1", body: `{"name": "Renamed"}`, wantStatus: http.StatusInternalServerError},
+		{name: "patch failing", repo: failing, method: http.MethodPatch, path: "/products/1", body: `{"name": "Renamed"}`, wantStatus: http.StatusInternalServerError},
+		{name: "delete failing", repo: failing, method: http.MethodDelete, path: "/products/1", wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox := data.NewMemoryOutboxRepository()
+
+			var repo data.ProductRepository = data.NewMemoryProductRepository(data.DefaultProducts(), outbox)
+			if tt.repo != nil {
+				repo = tt.repo(outbox)
+			}
+
+			rec := serve(productRouter(repo), tt.method, tt.path, tt.contentType, tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+
+			if queued := drainOutbox(t, outbox); len(queued) != 0 {
+				t.Fatalf("queued %d events, want none", len(queued))
+			}
+		})
+	}
+}
+
+// failingProductRepository fails reads and writes like an unreachable
+// database.
+type failingProductRepository struct {
+	data.ProductRepository
+}
+
+var errDatabase = errors.New("database unavailable")
+
+func failing(outbox *data.MemoryOutboxRepository) data.ProductRepository {
+	return failingProductRepository{data.NewMemoryProductRepository(data.DefaultProducts(), outbox)}
+}
+
+func (failingProductRepository) GetByID(ctx context.Context, id int) (data.ProductData, error) {
+	return data.ProductData{}, errDatabase
+}
+
+func (failingProductRepository) Create(ctx context.Context, product data.ProductData, outbox data.ProductOutbox) (data.ProductData, error) {
+	return data.ProductData{}, errDatabase
+}
+
+func (failingProductRepository) Update(ctx context.Context, product data.ProductData, outbox data.ProductOutbox) (data.ProductData, error) {
+	return data.ProductData{}, errDatabase
+}
+
+func (failingProductRepository) Delete(ctx context.Context, id int, outbox data.ProductOutbox) (data.ProductData, error) {
+	return data.ProductData{}, errDatabase
+}
+
+// conflictingProductRepository finds every new ID taken, as MongoDB does
+// while its ID counter trails the stored products.
+type conflictingProductRepository struct {
+	data.ProductRepository
+}
+
+func conflicting(outbox *data.MemoryOutboxRepository) data.ProductRepository {
+	return conflictingProductRepository{data.NewMemoryProductRepository(data.DefaultProducts(), outbox)}
+}
+
+func (conflictingProductRepository) Create(ctx context.Context, product data.ProductData, outbox data.ProductOutbox) (data.ProductData, error) {
+	return data.ProductData{}, data.ErrProductExists
+}
+
+func productRouter(repo data.ProductRepository) *mux.Router {
+	router := mux.NewRouter()
+	ProductRoutes(router, repo)
+	return router
+}
+
+func serve(router *mux.Router, method, path, contentType, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	r.Header.Set("Content-Type", contentType)
+
+	principal := &middlewares.Principal{Subject: testUserID, Roles: []string{"products:write"}}
+	r = r.WithContext(middlewares.WithPrincipal(r.Context(), principal))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, r)
+	return rec
+}
+
+// drainOutbox returns the queued messages the way the relay would produce
+// them, in order.
+func drainOutbox(t *testing.T, outbox *data.MemoryOutboxRepository) []*kafka.Message {
+	t.Helper()
+	return drainOutboxAt(t, outbox, time.Now().UTC())
+}
+
+func drainOutboxAt(t *testing.T, outbox *data.MemoryOutboxRepository, now time.Time) []*kafka.Message {
+	t.Helper()
+
+	var messages []*kafka.Message
+	for {
+		message, err := outbox.Claim(context.Background(), now, time.Minute)
+		if errors.Is(err, data.ErrOutboxEmpty) {
+			return messages
+		}
+		if err != nil {
+			t.Fatalf("Claim: %v", err)
+		}
+		if err := outbox.MarkSent(context.Background(), message.ID); err != nil {
+			t.Fatalf("MarkSent: %v", err)
+		}
+
+		messages = append(messages, &kafka.Message{
+			Topic:   message.Topic,
+			Key:     message.Key,
+			Value:   message.Value,
+			Headers: message.Headers,
+		})
+	}
+}
+
+func decodeProductEvent(t *testing.T, msg *kafka.Message) (events.Envelope, events.ProductChanged) {
+	t.Helper()
+
+	e, err := events.Decode(msg)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	var change events.ProductChanged
+	if err := json.Unmarshal(e.Data, &change); err != nil {
+		t.Fatalf("decode product change: %v", err)
+	}
+	return e, change
+}
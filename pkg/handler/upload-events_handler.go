@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"web-service/pkg/events"
+	"web-service/pkg/middlewares"
+	"web-service/pkg/utils"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	streamHeartbeat    = 15 * time.Second
+	streamWriteTimeout = 10 * time.Second
+	streamRetry        = 3 * time.Second
+	// streamBuffer is how many events a client may fall behind before it
+	// is disconnected and has to resume with Last-Event-ID.
+	streamBuffer = 64
+)
+
+type UploadEventsHandler struct {
+	hub *events.Hub
+}
+
+func NewUploadEventsHandler(hub *events.Hub) *UploadEventsHandler {
+	return &UploadEventsHandler{hub: hub}
+}
+
+// uploadEventFilter matches events by uploader, storage backend and MIME
+// type, where the MIME type may be a wildcard such as "image/*".
+func uploadEventFilter(r *http.Request) func(events.Envelope) bool {
+	query := r.URL.Query()
+	uploader := query.Get("uploader")
+	backend := query.Get("storage")
+	mimeType := query.Get("mimeType")
+
+	return func(e events.Envelope) bool {
+		if e.Type != events.TypeFileUploaded {
+			return false
+		}
+		if uploader != "" && e.UserID != uploader {
+			return false
+		}
+		if backend == "" && mimeType == "" {
+			return true
+		}
+
+		var file events.FileUploaded
+		if err := e.DecodeData(&file); err != nil {
+			return false
+		}
+		if backend != "" && file.Storage != backend {
+			return false
+		}
+		if prefix, ok := strings.CutSuffix(mimeType, "/*"); ok {
+			return strings.HasPrefix(file.MimeType, prefix+"/")
+		}
+		return mimeType == "" || file.MimeType == mimeType
+	}
+}
+
+// stream pushes upload events to the client as Server-Sent Events.
+func (h *UploadEventsHandler) stream(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+
+	client, replay, err := h.hub.Subscribe(uploadEventFilter(r), lastEventID, streamBuffer)
+	if err != nil {
+		utils.ResponseJson(w, http.StatusServiceUnavailable, utils.ServiceUnavailableError("Event stream is shutting down"))
+		return
+	}
+	defer h.hub.Unsubscribe(client)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	write := func(format string, args ...interface{}) bool {
+		rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		if _, err := fmt.Fprintf(w, format, args...); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+
+	writeEvent := func(e events.Envelope) bool {
+		payload, err := events.JSONCodec{}.Marshal(e)
+		if err != nil {
+			return write(": skipped event %s: %v\n\n", e.ID, err)
+		}
+		return write("id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, payload)
+	}
+
+	if !write("retry: %d\n\n", streamRetry.Milliseconds()) {
+		return
+	}
+	for _, e := range replay {
+		if !writeEvent(e) {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if !write(": ping\n\n") {
+				return
+			}
+		case e, ok := <-client.C:
+			if !ok {
+				if client.Dropped() {
+					write("event: overflow\ndata: {}\n\n")
+				}
+				return
+			}
+			if !writeEvent(e) {
+				return
+			}
+		}
+	}
+}
+
+// UploadEventRoutes must be registered before UploadRoutes so that
+// /uploads/events is not taken for an upload ID.
+func UploadEventRoutes(r *mux.Router, hub *events.Hub) {
+	h := NewUploadEventsHandler(hub)
+
+	r.HandleFunc("/uploads/events", middlewares.RequireRoles(h.stream, "uploads:read")).Methods(http.MethodGet)
+}
@@ -0,0 +1,272 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+	"web-service/pkg/data"
+	"web-service/pkg/events"
+	googledrive "web-service/pkg/google-drive"
+	"web-service/pkg/middlewares"
+	"web-service/pkg/storage"
+	"web-service/pkg/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type UploadHandler struct {
+	provider      storage.Provider
+	backend       string
+	repo          data.UploadRepository
+	policy        storage.Policy
+	errorResponse func(r *http.Request, err error) utils.Response
+}
+
+// multipartOverhead leaves room for the form boundaries and fields around
+// the file when limiting the request body.
+const multipartOverhead = 1 << 20
+
+func NewUploadHandler(provider storage.Provider, backend string, repo data.UploadRepository, policy storage.Policy) *UploadHandler {
+	return &UploadHandler{provider: provider, backend: backend, repo: repo, policy: policy, errorResponse: storageErrorResponse}
+}
+
+func storageErrorResponse(r *http.Request, err error) utils.Response {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return utils.NotFoundError("File not found", nil)
+	case errors.Is(err, googledrive.ErrNotConfigured):
+		return utils.ServiceUnavailableError("Google Drive is not configured on this server")
+	case errors.Is(err, googledrive.ErrTokenNotFound), errors.Is(err, googledrive.ErrReauthRequired):
+		return utils.UnauthorizedError("Google Drive is not authorized for this user, authorize it via /api/v1/googleDrives/auth/google")
+	}
+
+	log.Printf("Storage request failed: %v", err)
+	return utils.InternalServerError("Storage request failed: " + err.Error())
+}
+
+// countingWriter tracks how many bytes went through a TeeReader.
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+func (h *UploadHandler) upload(w http.ResponseWriter, r *http.Request) utils.Response {
+	store, err := h.provider(r.Context(), currentUserID(r))
+	if err != nil {
+		return h.errorResponse(r, err)
+	}
+
+	if h.policy.MaxSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, h.policy.MaxSize+multipartOverhead)
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return fileTooLarge(h.policy)
+		}
+		return utils.BadRequestError("Unable to read uploaded file: "+err.Error(), nil)
+	}
+	defer file.Close()
+
+	if err := h.policy.CheckSize(header.Size); err != nil {
+		return fileTooLarge(h.policy)
+	}
+
+	// The declared type comes from the client, so trust the content instead.
+	contentType, content, err := storage.DetectContentType(file)
+	if err != nil {
+		return utils.BadRequestError("Unable to read uploaded file: "+err.Error(), nil)
+	}
+	if err := h.policy.CheckType(contentType); err != nil {
+		return fileTypeNotAllowed(contentType)
+	}
+
+	folder := r.FormValue("folder")
+	if folder == "" {
+		folder = r.FormValue("folderId")
+	}
+
+	hash := sha256.New()
+	counter := &countingWriter{}
+	body := io.TeeReader(content, io.MultiWriter(hash, counter))
+
+	record := data.Upload{
+		Storage:  h.backend,
+		Name:     header.Filename,
+		MimeType: contentType,
+		Uploader: currentUserID(r),
+	}
+
+	object, err := store.Put(r.Context(), header.Filename, body, storage.PutOptions{
+		ContentType: record.MimeType,
+		Size:        header.Size,
+		Folder:      folder,
+	})
+	if err != nil {
+		record.Size = counter.n
+		record.Status = data.UploadStatusFailed
+		record.Error = err.Error()
+		h.record(r.Context(), record)
+
+		return h.errorResponse(r, err)
+	}
+
+	record.FileID = object.Key
+	record.Size = counter.n
+	record.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	record.Status = data.UploadStatusUploaded
+	h.record(r.Context(), record)
+
+	return utils.SuccessResponse("File uploaded successfully", object)
+}
+
+func fileTooLarge(policy storage.Policy) utils.Response {
+	return utils.PayloadTooLargeError(fmt.Sprintf("File exceeds the limit of %d bytes", policy.MaxSize))
+}
+
+func fileTypeNotAllowed(contentType string) utils.Response {
+	return utils.UnsupportedMediaTypeError(fmt.Sprintf("File type %s is not allowed", contentType))
+}
+
+// record adds the upload to the catalog, queueing its event in the outbox
+// when it succeeded. The file is already stored at this point, so a catalog
+// failure is logged rather than failing the request.
+func (h *UploadHandler) record(ctx context.Context, upload data.Upload) {
+	id, err := data.NewUploadID()
+	if err != nil {
+		log.Printf("Failed to record upload %q: %v", upload.Name, err)
+		return
+	}
+	upload.ID = id
+	upload.CreatedAt = time.Now().UTC()
+
+	var outbox []data.OutboxMessage
+	if upload.Status == data.UploadStatusUploaded {
+		message, err := fileUploadedMessage(upload)
+		if err != nil {
+			log.Printf("Failed to build upload event for %q: %v", upload.Name, err)
+		} else {
+			outbox = append(outbox, message)
+		}
+	}
+
+	if err := h.repo.Create(ctx, upload, outbox...); err != nil {
+		log.Printf("Failed to record upload %q: %v", upload.Name, err)
+	}
+}
+
+func fileUploadedMessage(upload data.Upload) (data.OutboxMessage, error) {
+	event, err := events.NewFileUploaded(upload.Uploader, events.FileUploaded{
+		UploadID: upload.ID,
+		FileID:   upload.FileID,
+		Storage:  upload.Storage,
+		Name:     upload.Name,
+		Size:     upload.Size,
+		MimeType: upload.MimeType,
+		SHA256:   upload.SHA256,
+	})
+	if err != nil {
+		return data.OutboxMessage{}, err
+	}
+	event.OccurredAt = upload.CreatedAt
+
+	message, err := events.Encode(events.TopicFileUploaded, event)
+	if err != nil {
+		return data.OutboxMessage{}, err
+	}
+	return data.NewOutboxMessage(message.Topic, message.Key, message.Value, message.Headers), nil
+}
+
+func (h *UploadHandler) getUploads(w http.ResponseWriter, r *http.Request) utils.Response {
+	query := r.URL.Query()
+
+	page, err := utils.ParsePage(query)
+	if err != nil {
+		return utils.BadRequestError("Invalid query: "+err.Error(), nil)
+	}
+
+	sortFields, err := utils.ParseSort(query.Get("sort"), data.UploadSortFields...)
+	if err != nil {
+		return utils.BadRequestError("Invalid query: "+err.Error(), nil)
+	}
+
+	from, err := parseTimeParam(query, "from")
+	if err != nil {
+		return utils.BadRequestError("Invalid query: "+err.Error(), nil)
+	}
+
+	to, err := parseTimeParam(query, "to")
+	if err != nil {
+		return utils.BadRequestError("Invalid query: "+err.Error(), nil)
+	}
+
+	result, err := h.repo.List(r.Context(), data.UploadQuery{
+		Uploader: query.Get("uploader"),
+		Name:     query.Get("name"),
+		MimeType: query.Get("mimeType"),
+		Status:   query.Get("status"),
+		From:     from,
+		To:       to,
+		Sort:     sortFields,
+		Page:     page,
+	})
+	if err != nil {
+		log.Printf("Failed to list uploads: %v", err)
+		return utils.InternalServerError("Failed to get uploads")
+	}
+
+	pagination := utils.NewPagination(page, result.Total)
+	utils.SetLinkHeader(w, r, pagination)
+
+	return utils.PaginatedResponse("Get all uploads successfully", result.Items, pagination)
+}
+
+func (h *UploadHandler) getUpload(w http.ResponseWriter, r *http.Request) utils.Response {
+	id := mux.Vars(r)["id"]
+
+	upload, err := h.repo.GetByID(r.Context(), id)
+	if errors.Is(err, data.ErrUploadNotFound) {
+		return utils.NotFoundError(fmt.Sprintf("Upload with id %s not found", id), nil)
+	}
+	if err != nil {
+		log.Printf("Failed to get upload %s: %v", id, err)
+		return utils.InternalServerError("Failed to get upload")
+	}
+
+	return utils.SuccessResponse("Get upload successfully", upload)
+}
+
+// parseTimeParam reads an optional RFC 3339 timestamp from the query.
+func parseTimeParam(query url.Values, name string) (*time.Time, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+	}
+	return &t, nil
+}
+
+func UploadRoutes(r *mux.Router, provider storage.Provider, backend string, repo data.UploadRepository, policy storage.Policy) {
+	h := NewUploadHandler(provider, backend, repo, policy)
+
+	r.HandleFunc("/upload", middlewares.RequireRoles(utils.WrapHandler(h.upload), "uploads:write")).Methods(http.MethodPost)
+	r.HandleFunc("/uploads", middlewares.RequireRoles(utils.WrapHandler(h.getUploads), "uploads:read")).Methods(http.MethodGet)
+	r.HandleFunc("/uploads/{id}", middlewares.RequireRoles(utils.WrapHandler(h.getUpload), "uploads:read")).Methods(http.MethodGet)
+}
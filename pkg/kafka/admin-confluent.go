@@ -0,0 +1,342 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	ckafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// metadataTimeout bounds metadata requests when ctx has no deadline.
+const metadataTimeout = 10 * time.Second
+
+type confluentAdmin struct {
+	client *ckafka.AdminClient
+}
+
+// partitionKey identifies a partition, ckafka.TopicPartition can't be used
+// as a map key across calls since it holds the topic by pointer.
+type partitionKey struct {
+	topic     string
+	partition int32
+}
+
+type watermark struct {
+	low  int64
+	high int64
+}
+
+func newConfluentAdmin(b *ConfluentBroker) (*confluentAdmin, error) {
+	client, err := ckafka.NewAdminClientFromProducer(b.producer)
+	if err != nil {
+		return nil, err
+	}
+	return &confluentAdmin{client: client}, nil
+}
+
+func (a *confluentAdmin) EnsureTopics(ctx context.Context, topics []TopicConfig) error {
+	metadata, err := a.metadata(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		create    []ckafka.TopicSpecification
+		grow      []ckafka.PartitionsSpecification
+		retention []ckafka.ConfigResource
+	)
+
+	for _, topic := range topics {
+		config := map[string]string{}
+		if topic.Retention > 0 {
+			config["retention.ms"] = strconv.FormatInt(topic.Retention.Milliseconds(), 10)
+		}
+
+		existing, ok := metadata.Topics[topic.Name]
+		if !ok || existing.Error.Code() == ckafka.ErrUnknownTopicOrPart {
+			create = append(create, ckafka.TopicSpecification{
+				Topic:             topic.Name,
+				NumPartitions:     topic.Partitions,
+				ReplicationFactor: topic.ReplicationFactor,
+				Config:            config,
+			})
+			continue
+		}
+
+		if len(existing.Partitions) < topic.Partitions {
+			grow = append(grow, ckafka.PartitionsSpecification{Topic: topic.Name, IncreaseTo: topic.Partitions})
+		}
+
+		// Kafka has no way to change the replication factor of a topic
+		// short of reassigning its partitions by hand.
+		if len(existing.Partitions) > 0 && len(existing.Partitions[0].Replicas) != topic.ReplicationFactor {
+			log.Printf("Kafka topic %s has replication factor %d, %d is configured",
+				topic.Name, len(existing.Partitions[0].Replicas), topic.ReplicationFactor)
+		}
+
+		if len(config) > 0 {
+			retention = append(retention, ckafka.ConfigResource{
+				Type: ckafka.ResourceTopic,
+				Name: topic.Name,
+				Config: ckafka.StringMapToIncrementalConfigEntries(config, map[string]ckafka.AlterConfigOpType{
+					"retention.ms": ckafka.AlterConfigOpTypeSet,
+				}),
+			})
+		}
+	}
+
+	if len(create) > 0 {
+		results, err := a.client.CreateTopics(ctx, create)
+		if err != nil {
+			return err
+		}
+		if err := topicResultErrors("create", results); err != nil {
+			return err
+		}
+		for _, spec := range create {
+			log.Printf("Created Kafka topic %s with %d partitions", spec.Topic, spec.NumPartitions)
+		}
+	}
+
+	if len(grow) > 0 {
+		results, err := a.client.CreatePartitions(ctx, grow)
+		if err != nil {
+			return err
+		}
+		if err := topicResultErrors("add partitions to", results); err != nil {
+			return err
+		}
+	}
+
+	if len(retention) > 0 {
+		results, err := a.client.IncrementalAlterConfigs(ctx, retention)
+		if err != nil {
+			return err
+		}
+
+		var errs []error
+		for _, result := range results {
+			if result.Error.Code() != ckafka.ErrNoError {
+				errs = append(errs, fmt.Errorf("kafka: set retention of %s: %w", result.Name, result.Error))
+			}
+		}
+		if err := errors.Join(errs...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Topics lists the topics of the cluster, leaving out internal ones such as
+// __consumer_offsets.
+func (a *confluentAdmin) Topics(ctx context.Context) ([]TopicInfo, error) {
+	metadata, err := a.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []ckafka.TopicMetadata
+	for name, topic := range metadata.Topics {
+		if !strings.HasPrefix(name, "__") && topic.Error.Code() == ckafka.ErrNoError {
+			found = append(found, topic)
+		}
+	}
+	return a.topicInfos(ctx, found)
+}
+
+func (a *confluentAdmin) Topic(ctx context.Context, name string) (TopicInfo, error) {
+	metadata, err := a.metadata(ctx)
+	if err != nil {
+		return TopicInfo{}, err
+	}
+
+	topic, ok := metadata.Topics[name]
+	if !ok || topic.Error.Code() == ckafka.ErrUnknownTopicOrPart {
+		return TopicInfo{}, ErrTopicNotFound
+	}
+
+	topics, err := a.topicInfos(ctx, []ckafka.TopicMetadata{topic})
+	if err != nil {
+		return TopicInfo{}, err
+	}
+	return topics[0], nil
+}
+
+func (a *confluentAdmin) Groups(ctx context.Context) ([]GroupInfo, error) {
+	result, err := a.client.ListConsumerGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Valid) == 0 && len(result.Errors) > 0 {
+		return nil, errors.Join(result.Errors...)
+	}
+	for _, err := range result.Errors {
+		log.Printf("Failed to list some Kafka consumer groups: %v", err)
+	}
+
+	groups := make([]GroupInfo, 0, len(result.Valid))
+	for _, listing := range result.Valid {
+		groups = append(groups, GroupInfo{ID: listing.GroupID, State: listing.State.String()})
+	}
+
+	sort.Slice(groups, func(i, j int) bool { return groups[i].ID < groups[j].ID })
+	return groups, nil
+}
+
+func (a *confluentAdmin) GroupOffsets(ctx context.Context, group string) (GroupOffsets, error) {
+	offsets := GroupOffsets{Group: group}
+
+	result, err := a.client.ListConsumerGroupOffsets(ctx, []ckafka.ConsumerGroupTopicPartitions{{Group: group}})
+	if err != nil {
+		return offsets, err
+	}
+	if len(result.ConsumerGroupsTopicPartitions) == 0 || len(result.ConsumerGroupsTopicPartitions[0].Partitions) == 0 {
+		return offsets, ErrGroupNotFound
+	}
+
+	partitions := result.ConsumerGroupsTopicPartitions[0].Partitions
+	watermarks, err := a.watermarks(ctx, partitions)
+	if err != nil {
+		return offsets, err
+	}
+
+	for _, tp := range partitions {
+		if tp.Error != nil {
+			return offsets, tp.Error
+		}
+
+		committed := int64(-1)
+		if tp.Offset >= 0 {
+			committed = int64(tp.Offset)
+		}
+
+		w := watermarks[partitionKey{topic: *tp.Topic, partition: tp.Partition}]
+		partition := GroupPartitionOffset{
+			Topic:         *tp.Topic,
+			Partition:     tp.Partition,
+			Committed:     committed,
+			HighWatermark: w.high,
+			Lag:           lag(committed, w.low, w.high),
+		}
+		offsets.Partitions = append(offsets.Partitions, partition)
+		offsets.Lag += partition.Lag
+	}
+
+	sort.Slice(offsets.Partitions, func(i, j int) bool {
+		pi, pj := offsets.Partitions[i], offsets.Partitions[j]
+		if pi.Topic != pj.Topic {
+			return pi.Topic < pj.Topic
+		}
+		return pi.Partition < pj.Partition
+	})
+	return offsets, nil
+}
+
+// Close releases the admin client, the producer it shares stays open.
+func (a *confluentAdmin) Close() error {
+	a.client.Close()
+	return nil
+}
+
+func (a *confluentAdmin) metadata(ctx context.Context) (*ckafka.Metadata, error) {
+	timeout := metadataTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	if timeout <= 0 {
+		return nil, context.DeadlineExceeded
+	}
+
+	return a.client.GetMetadata(nil, true, int(timeout.Milliseconds()))
+}
+
+func (a *confluentAdmin) topicInfos(ctx context.Context, topics []ckafka.TopicMetadata) ([]TopicInfo, error) {
+	var partitions []ckafka.TopicPartition
+	for _, topic := range topics {
+		for _, p := range topic.Partitions {
+			name := topic.Topic
+			partitions = append(partitions, ckafka.TopicPartition{Topic: &name, Partition: p.ID})
+		}
+	}
+
+	watermarks, err := a.watermarks(ctx, partitions)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]TopicInfo, 0, len(topics))
+	for _, topic := range topics {
+		info := TopicInfo{Name: topic.Topic, Partitions: make([]PartitionInfo, 0, len(topic.Partitions))}
+		for _, p := range topic.Partitions {
+			w := watermarks[partitionKey{topic: topic.Topic, partition: p.ID}]
+			info.Partitions = append(info.Partitions, PartitionInfo{
+				ID:             p.ID,
+				Leader:         p.Leader,
+				Replicas:       p.Replicas,
+				InSyncReplicas: p.Isrs,
+				LowWatermark:   w.low,
+				HighWatermark:  w.high,
+			})
+		}
+
+		sort.Slice(info.Partitions, func(i, j int) bool { return info.Partitions[i].ID < info.Partitions[j].ID })
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
+// watermarks looks up the earliest and latest offsets of the partitions.
+func (a *confluentAdmin) watermarks(ctx context.Context, partitions []ckafka.TopicPartition) (map[partitionKey]watermark, error) {
+	watermarks := make(map[partitionKey]watermark, len(partitions))
+	if len(partitions) == 0 {
+		return watermarks, nil
+	}
+
+	for _, spec := range []ckafka.OffsetSpec{ckafka.EarliestOffsetSpec, ckafka.LatestOffsetSpec} {
+		request := make(map[ckafka.TopicPartition]ckafka.OffsetSpec, len(partitions))
+		for _, tp := range partitions {
+			request[ckafka.TopicPartition{Topic: tp.Topic, Partition: tp.Partition}] = spec
+		}
+
+		result, err := a.client.ListOffsets(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		for tp, info := range result.ResultInfos {
+			if info.Error.Code() != ckafka.ErrNoError {
+				return nil, fmt.Errorf("kafka: list offsets of %s [%d]: %w", *tp.Topic, tp.Partition, info.Error)
+			}
+
+			key := partitionKey{topic: *tp.Topic, partition: tp.Partition}
+			w := watermarks[key]
+			if spec == ckafka.EarliestOffsetSpec {
+				w.low = int64(info.Offset)
+			} else {
+				w.high = int64(info.Offset)
+			}
+			watermarks[key] = w
+		}
+	}
+	return watermarks, nil
+}
+
+func topicResultErrors(op string, results []ckafka.TopicResult) error {
+	var errs []error
+	for _, result := range results {
+		code := result.Error.Code()
+		if code != ckafka.ErrNoError && code != ckafka.ErrTopicAlreadyExists {
+			errs = append(errs, fmt.Errorf("kafka: %s topic %s: %w", op, result.Topic, result.Error))
+		}
+	}
+	return errors.Join(errs...)
+}
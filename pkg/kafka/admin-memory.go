@@ -0,0 +1,135 @@
+package kafka
+
+import (
+	"context"
+	"sort"
+)
+
+// memoryAdmin reports the topics and groups of a MemoryBroker, whose topics
+// always have a single partition and no retention.
+type memoryAdmin struct {
+	broker *MemoryBroker
+}
+
+func (a *memoryAdmin) EnsureTopics(ctx context.Context, topics []TopicConfig) error {
+	b := a.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrBrokerClosed
+	}
+
+	for _, topic := range topics {
+		if _, ok := b.topics[topic.Name]; !ok {
+			b.topics[topic.Name] = nil
+		}
+	}
+	return nil
+}
+
+func (a *memoryAdmin) Topics(ctx context.Context) ([]TopicInfo, error) {
+	b := a.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	topics := make([]TopicInfo, 0, len(b.topics))
+	for name := range b.topics {
+		topics = append(topics, b.topicInfo(name))
+	}
+
+	sort.Slice(topics, func(i, j int) bool { return topics[i].Name < topics[j].Name })
+	return topics, nil
+}
+
+func (a *memoryAdmin) Topic(ctx context.Context, name string) (TopicInfo, error) {
+	b := a.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.topics[name]; !ok {
+		return TopicInfo{}, ErrTopicNotFound
+	}
+	return b.topicInfo(name), nil
+}
+
+func (a *memoryAdmin) Groups(ctx context.Context) ([]GroupInfo, error) {
+	b := a.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	members := make(map[string]int)
+	for key, c := range b.cursors {
+		members[key.group] += c.members
+	}
+
+	groups := make([]GroupInfo, 0, len(members))
+	for group, n := range members {
+		state := "Empty"
+		if n > 0 {
+			state = "Stable"
+		}
+		groups = append(groups, GroupInfo{ID: group, State: state})
+	}
+
+	sort.Slice(groups, func(i, j int) bool { return groups[i].ID < groups[j].ID })
+	return groups, nil
+}
+
+func (a *memoryAdmin) GroupOffsets(ctx context.Context, group string) (GroupOffsets, error) {
+	b := a.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	offsets := GroupOffsets{Group: group}
+	for key, c := range b.cursors {
+		if key.group != group {
+			continue
+		}
+
+		committed := int64(-1)
+		if c.hasCommitted {
+			committed = c.committed
+		}
+
+		high := int64(len(b.topics[key.topic]))
+		partition := GroupPartitionOffset{
+			Topic:         key.topic,
+			Partition:     0,
+			Committed:     committed,
+			HighWatermark: high,
+			Lag:           lag(committed, 0, high),
+		}
+		offsets.Partitions = append(offsets.Partitions, partition)
+		offsets.Lag += partition.Lag
+	}
+
+	if offsets.Partitions == nil {
+		return offsets, ErrGroupNotFound
+	}
+
+	sort.Slice(offsets.Partitions, func(i, j int) bool {
+		return offsets.Partitions[i].Topic < offsets.Partitions[j].Topic
+	})
+	return offsets, nil
+}
+
+// Close leaves the broker open, it is closed on its own.
+func (a *memoryAdmin) Close() error {
+	return nil
+}
+
+// topicInfo must be called with b.mu held.
+func (b *MemoryBroker) topicInfo(name string) TopicInfo {
+	return TopicInfo{
+		Name: name,
+		Partitions: []PartitionInfo{{
+			ID:             0,
+			Leader:         0,
+			Replicas:       []int32{0},
+			InSyncReplicas: []int32{0},
+			LowWatermark:   0,
+			HighWatermark:  int64(len(b.topics[name])),
+		}},
+	}
+}
@@ -0,0 +1,100 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrTopicNotFound = errors.New("kafka: topic not found")
+	ErrGroupNotFound = errors.New("kafka: consumer group not found")
+)
+
+// TopicConfig declares a topic the service relies on.
+type TopicConfig struct {
+	Name              string
+	Partitions        int
+	ReplicationFactor int
+	// Retention is how long the broker keeps messages, zero keeps the
+	// broker default.
+	Retention time.Duration
+}
+
+type TopicInfo struct {
+	Name       string          `json:"name"`
+	Partitions []PartitionInfo `json:"partitions"`
+}
+
+// PartitionInfo describes a partition. The watermarks are the offsets of
+// the oldest retained message and of the next message to be written.
+type PartitionInfo struct {
+	ID             int32   `json:"id"`
+	Leader         int32   `json:"leader"`
+	Replicas       []int32 `json:"replicas"`
+	InSyncReplicas []int32 `json:"inSyncReplicas"`
+	LowWatermark   int64   `json:"lowWatermark"`
+	HighWatermark  int64   `json:"highWatermark"`
+}
+
+type GroupInfo struct {
+	ID    string `json:"id"`
+	State string `json:"state"`
+}
+
+// GroupOffsets holds the committed offsets of a consumer group and how far
+// behind the end of each partition they are.
+type GroupOffsets struct {
+	Group      string                 `json:"group"`
+	Lag        int64                  `json:"lag"`
+	Partitions []GroupPartitionOffset `json:"partitions"`
+}
+
+// GroupPartitionOffset is the position of a group on one partition.
+// Committed is -1 when the group never committed on the partition.
+type GroupPartitionOffset struct {
+	Topic         string `json:"topic"`
+	Partition     int32  `json:"partition"`
+	Committed     int64  `json:"committed"`
+	HighWatermark int64  `json:"highWatermark"`
+	Lag           int64  `json:"lag"`
+}
+
+// Admin manages and inspects the topics and consumer groups of a broker.
+type Admin interface {
+	// EnsureTopics creates the missing topics and brings existing ones up to
+	// the declared partitions and retention.
+	EnsureTopics(ctx context.Context, topics []TopicConfig) error
+	Topics(ctx context.Context) ([]TopicInfo, error)
+	Topic(ctx context.Context, name string) (TopicInfo, error)
+	Groups(ctx context.Context) ([]GroupInfo, error)
+	GroupOffsets(ctx context.Context, group string) (GroupOffsets, error)
+	Close() error
+}
+
+// NewAdmin returns an Admin sharing the connection of b.
+func NewAdmin(b Broker) (Admin, error) {
+	switch b := b.(type) {
+	case *ConfluentBroker:
+		return newConfluentAdmin(b)
+	case *MemoryBroker:
+		return &memoryAdmin{broker: b}, nil
+	case nil:
+		return nil, ErrNoBroker
+	default:
+		return nil, fmt.Errorf("kafka: %T does not support administration", b)
+	}
+}
+
+// lag is how many messages a group still has to consume on a partition. A
+// group without a committed offset starts from the low watermark.
+func lag(committed, low, high int64) int64 {
+	if committed < 0 {
+		committed = low
+	}
+	if committed >= high {
+		return 0
+	}
+	return high - committed
+}
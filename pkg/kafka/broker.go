@@ -0,0 +1,90 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrBrokerClosed = errors.New("kafka: broker closed")
+	ErrNoBroker     = errors.New("kafka: broker not initialized")
+)
+
+type Message struct {
+	Topic     string
+	Key       []byte
+	Value     []byte
+	Headers   map[string]string
+	Partition int32
+	Offset    int64
+	Timestamp time.Time
+}
+
+// DeliveryFunc receives the delivery report of a message. err is nil once
+// the broker acknowledged the message.
+type DeliveryFunc func(msg *Message, err error)
+
+// Broker is the transport used by the service to publish and consume events.
+type Broker interface {
+	// Produce enqueues a message for delivery to msg.Topic without waiting
+	// for the delivery report.
+	Produce(ctx context.Context, msg *Message) error
+	// ProduceAsync enqueues a message and calls onDelivery, when not nil,
+	// with its delivery report.
+	ProduceAsync(ctx context.Context, msg *Message, onDelivery DeliveryFunc) error
+	// Subscribe joins a consumer group on the given topics.
+	Subscribe(topics []string, opts SubscribeOptions) (Subscription, error)
+	Close() error
+}
+
+type SubscribeOptions struct {
+	// GroupID overrides the broker's consumer group.
+	GroupID string
+	// FromLatest starts a group without committed offsets at the end of
+	// the topics instead of the beginning.
+	FromLatest bool
+}
+
+// Subscription delivers messages of a consumer group member. Offsets are
+// only committed when Commit is called.
+type Subscription interface {
+	// Fetch blocks until a message is available or ctx is done.
+	Fetch(ctx context.Context) (*Message, error)
+	Commit(ctx context.Context, msg *Message) error
+	Close() error
+}
+
+// ProduceSync produces msg on b and waits for its delivery report. When ctx
+// ends first the message may still be delivered later.
+func ProduceSync(ctx context.Context, b Broker, msg *Message) error {
+	done := make(chan error, 1)
+
+	err := b.ProduceAsync(ctx, msg, func(_ *Message, err error) {
+		done <- err
+	})
+	if err != nil {
+		return err
+	}
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// NewBroker creates the broker of driver. The memory driver ignores the
+// connection settings.
+func NewBroker(driver, brokers, groupID string, security Security) (Broker, error) {
+	switch driver {
+	case "", "confluent":
+		return NewConfluentBroker(brokers, groupID, security)
+	case "memory":
+		return NewMemoryBroker(), nil
+	default:
+		return nil, fmt.Errorf("kafka: unknown driver %q", driver)
+	}
+}
@@ -0,0 +1,311 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+// fetchTimeout leaves a real cluster time to assign partitions to a new
+// group member.
+const fetchTimeout = 30 * time.Second
+
+func TestMemoryBroker(t *testing.T) {
+	testBroker(t, func() Broker { return NewMemoryBroker() })
+}
+
+// TestConfluentBroker runs the broker contract against the cluster in
+// KAFKA_BROKERS, such as the one in docker-compose-kafka.yaml.
+func TestConfluentBroker(t *testing.T) {
+	brokers := os.Getenv("KAFKA_BROKERS")
+	if brokers == "" {
+		t.Skip("KAFKA_BROKERS is not set")
+	}
+
+	testBroker(t, func() Broker {
+		b, err := NewConfluentBroker(brokers, "broker-test", Security{})
+		if err != nil {
+			t.Fatalf("NewConfluentBroker: %v", err)
+		}
+		return b
+	})
+}
+
+// testBroker checks the behaviour the service relies on from every Broker.
+// Each subtest uses its own topics and groups, so it also runs against a
+// shared cluster.
+func testBroker(t *testing.T, newBroker func() Broker) {
+	t.Run("fetch returns messages in produced order", func(t *testing.T) {
+		b, topic := setupBroker(t, newBroker)
+
+		for i := 0; i < 3; i++ {
+			produce(t, b, &Message{
+				Topic:   topic,
+				Key:     []byte("key"),
+				Value:   []byte(fmt.Sprintf("message-%d", i)),
+				Headers: map[string]string{"index": fmt.Sprint(i)},
+			})
+		}
+
+		sub := subscribe(t, b, []string{topic}, SubscribeOptions{GroupID: uniqueName("group")})
+		for i := 0; i < 3; i++ {
+			msg := fetch(t, sub)
+			if want := fmt.Sprintf("message-%d", i); string(msg.Value) != want {
+				t.Fatalf("message %d: value = %q, want %q", i, msg.Value, want)
+			}
+			if string(msg.Key) != "key" || msg.Headers["index"] != fmt.Sprint(i) {
+				t.Fatalf("message %d: key = %q, headers = %v", i, msg.Key, msg.Headers)
+			}
+			if msg.Topic != topic {
+				t.Fatalf("message %d: topic = %q, want %q", i, msg.Topic, topic)
+			}
+		}
+	})
+
+	t.Run("produce sync reports delivery", func(t *testing.T) {
+		b, topic := setupBroker(t, newBroker)
+
+		ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+		defer cancel()
+
+		if err := ProduceSync(ctx, b, &Message{Topic: topic, Value: []byte("sync")}); err != nil {
+			t.Fatalf("ProduceSync: %v", err)
+		}
+
+		sub := subscribe(t, b, []string{topic}, SubscribeOptions{GroupID: uniqueName("group")})
+		if msg := fetch(t, sub); string(msg.Value) != "sync" {
+			t.Fatalf("value = %q, want %q", msg.Value, "sync")
+		}
+	})
+
+	t.Run("uncommitted messages are redelivered", func(t *testing.T) {
+		b, topic := setupBroker(t, newBroker)
+		group := uniqueName("group")
+
+		produce(t, b, &Message{Topic: topic, Value: []byte("first")})
+		produce(t, b, &Message{Topic: topic, Value: []byte("second")})
+
+		sub := subscribe(t, b, []string{topic}, SubscribeOptions{GroupID: group})
+		commit(t, sub, fetch(t, sub))
+		if msg := fetch(t, sub); string(msg.Value) != "second" {
+			t.Fatalf("value = %q, want %q", msg.Value, "second")
+		}
+		closeSubscription(t, sub)
+
+		sub = subscribe(t, b, []string{topic}, SubscribeOptions{GroupID: group})
+		if msg := fetch(t, sub); string(msg.Value) != "second" {
+			t.Fatalf("after resubscribing: value = %q, want the uncommitted %q", msg.Value, "second")
+		}
+	})
+
+	t.Run("commit advances the group", func(t *testing.T) {
+		b, topic := setupBroker(t, newBroker)
+		group := uniqueName("group")
+
+		produce(t, b, &Message{Topic: topic, Value: []byte("first")})
+		produce(t, b, &Message{Topic: topic, Value: []byte("second")})
+
+		sub := subscribe(t, b, []string{topic}, SubscribeOptions{GroupID: group})
+		commit(t, sub, fetch(t, sub))
+		commit(t, sub, fetch(t, sub))
+		closeSubscription(t, sub)
+
+		produce(t, b, &Message{Topic: topic, Value: []byte("third")})
+
+		sub = subscribe(t, b, []string{topic}, SubscribeOptions{GroupID: group})
+		if msg := fetch(t, sub); string(msg.Value) != "third" {
+			t.Fatalf("value = %q, want %q", msg.Value, "third")
+		}
+	})
+
+	t.Run("groups have their own cursors", func(t *testing.T) {
+		b, topic := setupBroker(t, newBroker)
+
+		produce(t, b, &Message{Topic: topic, Value: []byte("shared")})
+
+		first := subscribe(t, b, []string{topic}, SubscribeOptions{GroupID: uniqueName("first")})
+		commit(t, first, fetch(t, first))
+
+		second := subscribe(t, b, []string{topic}, SubscribeOptions{GroupID: uniqueName("second")})
+		if msg := fetch(t, second); string(msg.Value) != "shared" {
+			t.Fatalf("second group: value = %q, want %q", msg.Value, "shared")
+		}
+	})
+
+	t.Run("from latest skips earlier messages", func(t *testing.T) {
+		b, topic := setupBroker(t, newBroker)
+
+		produce(t, b, &Message{Topic: topic, Value: []byte("old")})
+
+		sub := subscribe(t, b, []string{topic}, SubscribeOptions{GroupID: uniqueName("group"), FromLatest: true})
+
+		// A cluster only resolves the latest offset once the member is
+		// assigned, so keep producing until a message gets through.
+		deadline := time.Now().Add(fetchTimeout)
+		for i := 0; ; i++ {
+			produce(t, b, &Message{Topic: topic, Value: []byte(fmt.Sprintf("new-%d", i))})
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			msg, err := sub.Fetch(ctx)
+			cancel()
+
+			if err == nil {
+				if string(msg.Value) == "old" {
+					t.Fatal("FromLatest delivered a message produced before subscribing")
+				}
+				return
+			}
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("Fetch: %v", err)
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("no message received")
+			}
+		}
+	})
+
+	t.Run("group offsets report a missing commit as -1", func(t *testing.T) {
+		b, topic := setupBroker(t, newBroker)
+		group := uniqueName("group")
+
+		produce(t, b, &Message{Topic: topic, Value: []byte("first")})
+		produce(t, b, &Message{Topic: topic, Value: []byte("second")})
+
+		sub := subscribe(t, b, []string{topic}, SubscribeOptions{GroupID: group})
+		first := fetch(t, sub)
+
+		// A cluster may leave out partitions without a committed offset.
+		offsets, err := groupOffsets(t, b, group)
+		if err != nil && !errors.Is(err, ErrGroupNotFound) {
+			t.Fatalf("GroupOffsets: %v", err)
+		}
+		for _, p := range offsets.Partitions {
+			if p.Topic == topic && (p.Committed != -1 || p.Lag != 2) {
+				t.Fatalf("before committing: committed = %d, lag = %d, want -1 and 2", p.Committed, p.Lag)
+			}
+		}
+
+		commit(t, sub, first)
+
+		offsets, err = groupOffsets(t, b, group)
+		if err != nil {
+			t.Fatalf("GroupOffsets: %v", err)
+		}
+		if len(offsets.Partitions) != 1 || offsets.Partitions[0].Committed != 1 || offsets.Partitions[0].Lag != 1 {
+			t.Fatalf("after committing: partitions = %+v, want committed 1 and lag 1", offsets.Partitions)
+		}
+	})
+
+	t.Run("closed broker rejects produce and subscribe", func(t *testing.T) {
+		b, topic := setupBroker(t, newBroker)
+
+		if err := b.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+
+		if err := b.Produce(context.Background(), &Message{Topic: topic}); !errors.Is(err, ErrBrokerClosed) {
+			t.Fatalf("Produce after Close: err = %v, want %v", err, ErrBrokerClosed)
+		}
+		if _, err := b.Subscribe([]string{topic}, SubscribeOptions{}); !errors.Is(err, ErrBrokerClosed) {
+			t.Fatalf("Subscribe after Close: err = %v, want %v", err, ErrBrokerClosed)
+		}
+	})
+}
+
+// setupBroker returns a new broker, closed at the end of the test, and a
+// topic of its own.
+func setupBroker(t *testing.T, newBroker func() Broker) (Broker, string) {
+	t.Helper()
+
+	b := newBroker()
+	t.Cleanup(func() { b.Close() })
+
+	topic := uniqueName("topic")
+
+	admin, err := NewAdmin(b)
+	if err != nil {
+		t.Fatalf("NewAdmin: %v", err)
+	}
+	defer admin.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+
+	if err := admin.EnsureTopics(ctx, []TopicConfig{{Name: topic, Partitions: 1, ReplicationFactor: 1}}); err != nil {
+		t.Fatalf("EnsureTopics: %v", err)
+	}
+	return b, topic
+}
+
+func groupOffsets(t *testing.T, b Broker, group string) (GroupOffsets, error) {
+	t.Helper()
+
+	admin, err := NewAdmin(b)
+	if err != nil {
+		t.Fatalf("NewAdmin: %v", err)
+	}
+	defer admin.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+
+	return admin.GroupOffsets(ctx, group)
+}
+
+func uniqueName(kind string) string {
+	return fmt.Sprintf("test-%s-%d", kind, time.Now().UnixNano())
+}
+
+func produce(t *testing.T, b Broker, msg *Message) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+
+	if err := ProduceSync(ctx, b, msg); err != nil {
+		t.Fatalf("produce to %s: %v", msg.Topic, err)
+	}
+}
+
+func subscribe(t *testing.T, b Broker, topics []string, opts SubscribeOptions) Subscription {
+	t.Helper()
+
+	sub, err := b.Subscribe(topics, opts)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	t.Cleanup(func() { sub.Close() })
+	return sub
+}
+
+func fetch(t *testing.T, sub Subscription) *Message {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+
+	msg, err := sub.Fetch(ctx)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	return msg
+}
+
+func commit(t *testing.T, sub Subscription, msg *Message) {
+	t.Helper()
+
+	if err := sub.Commit(context.Background(), msg); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+}
+
+func closeSubscription(t *testing.T, sub Subscription) {
+	t.Helper()
+
+	if err := sub.Close(); err != nil {
+		t.Fatalf("Close subscription: %v", err)
+	}
+}
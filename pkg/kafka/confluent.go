@@ -0,0 +1,215 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	ckafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+const (
+	pollInterval = 100 * time.Millisecond
+	flushTimeout = 5 * time.Second
+)
+
+type ConfluentBroker struct {
+	brokers  string
+	groupID  string
+	security Security
+	producer *ckafka.Producer
+	closed   atomic.Bool
+}
+
+func NewConfluentBroker(brokers, groupID string, security Security) (*ConfluentBroker, error) {
+	b := &ConfluentBroker{brokers: brokers, groupID: groupID, security: security}
+
+	// Idempotence keeps retries from duplicating or reordering messages.
+	producer, err := ckafka.NewProducer(b.configMap(ckafka.ConfigMap{
+		"enable.idempotence": true,
+		"acks":               "all",
+	}))
+	if err != nil {
+		return nil, err
+	}
+	b.producer = producer
+
+	go b.handleEvents()
+
+	return b, nil
+}
+
+func (b *ConfluentBroker) configMap(extra ckafka.ConfigMap) *ckafka.ConfigMap {
+	cfg := b.security.configMap()
+	cfg["bootstrap.servers"] = b.brokers
+
+	for k, v := range extra {
+		cfg[k] = v
+	}
+	return &cfg
+}
+
+func (b *ConfluentBroker) handleEvents() {
+	for e := range b.producer.Events() {
+		switch ev := e.(type) {
+		case *ckafka.Message:
+			err := ev.TopicPartition.Error
+			if err != nil {
+				log.Printf("Kafka delivery failed: %v", err)
+			}
+
+			msg := fromConfluentMessage(ev)
+			deliveries.delivered(msg.Topic, err)
+			if onDelivery, ok := ev.Opaque.(DeliveryFunc); ok {
+				onDelivery(msg, err)
+			}
+		case ckafka.Error:
+			log.Printf("Kafka producer error: %v", ev)
+		}
+	}
+}
+
+func (b *ConfluentBroker) Produce(ctx context.Context, msg *Message) error {
+	return b.ProduceAsync(ctx, msg, nil)
+}
+
+func (b *ConfluentBroker) ProduceAsync(ctx context.Context, msg *Message, onDelivery DeliveryFunc) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if b.closed.Load() {
+		return ErrBrokerClosed
+	}
+
+	out := toConfluentMessage(msg)
+	if onDelivery != nil {
+		out.Opaque = onDelivery
+	}
+
+	if err := b.producer.Produce(out, nil); err != nil {
+		return err
+	}
+	deliveries.produced(msg.Topic)
+	return nil
+}
+
+func (b *ConfluentBroker) Subscribe(topics []string, opts SubscribeOptions) (Subscription, error) {
+	if b.closed.Load() {
+		return nil, ErrBrokerClosed
+	}
+
+	groupID := b.groupID
+	if opts.GroupID != "" {
+		groupID = opts.GroupID
+	}
+
+	offsetReset := "earliest"
+	if opts.FromLatest {
+		offsetReset = "latest"
+	}
+
+	consumer, err := ckafka.NewConsumer(b.configMap(ckafka.ConfigMap{
+		"group.id":           groupID,
+		"auto.offset.reset":  offsetReset,
+		"enable.auto.commit": false,
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := consumer.SubscribeTopics(topics, nil); err != nil {
+		consumer.Close()
+		return nil, err
+	}
+
+	return &confluentSubscription{consumer: consumer}, nil
+}
+
+func (b *ConfluentBroker) Close() error {
+	if !b.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	remaining := b.producer.Flush(int(flushTimeout.Milliseconds()))
+	b.producer.Close()
+
+	if remaining > 0 {
+		return fmt.Errorf("kafka: %d messages were not delivered before closing", remaining)
+	}
+	return nil
+}
+
+type confluentSubscription struct {
+	consumer *ckafka.Consumer
+}
+
+func (s *confluentSubscription) Fetch(ctx context.Context) (*Message, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		msg, err := s.consumer.ReadMessage(pollInterval)
+		if err != nil {
+			if kerr, ok := err.(ckafka.Error); ok && kerr.IsTimeout() {
+				continue
+			}
+			return nil, err
+		}
+
+		return fromConfluentMessage(msg), nil
+	}
+}
+
+func (s *confluentSubscription) Commit(ctx context.Context, msg *Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	topic := msg.Topic
+	_, err := s.consumer.CommitOffsets([]ckafka.TopicPartition{{
+		Topic:     &topic,
+		Partition: msg.Partition,
+		Offset:    ckafka.Offset(msg.Offset + 1),
+	}})
+	return err
+}
+
+func (s *confluentSubscription) Close() error {
+	return s.consumer.Close()
+}
+
+func toConfluentMessage(msg *Message) *ckafka.Message {
+	topic := msg.Topic
+	out := &ckafka.Message{
+		TopicPartition: ckafka.TopicPartition{Topic: &topic, Partition: ckafka.PartitionAny},
+		Key:            msg.Key,
+		Value:          msg.Value,
+	}
+	for k, v := range msg.Headers {
+		out.Headers = append(out.Headers, ckafka.Header{Key: k, Value: []byte(v)})
+	}
+	return out
+}
+
+func fromConfluentMessage(msg *ckafka.Message) *Message {
+	out := &Message{
+		Key:       msg.Key,
+		Value:     msg.Value,
+		Partition: msg.TopicPartition.Partition,
+		Offset:    int64(msg.TopicPartition.Offset),
+		Timestamp: msg.Timestamp,
+	}
+	if msg.TopicPartition.Topic != nil {
+		out.Topic = *msg.TopicPartition.Topic
+	}
+	if len(msg.Headers) > 0 {
+		out.Headers = make(map[string]string, len(msg.Headers))
+		for _, h := range msg.Headers {
+			out.Headers[h.Key] = string(h.Value)
+		}
+	}
+	return out
+}
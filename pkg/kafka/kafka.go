@@ -0,0 +1,163 @@
+package kafka
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	mu            sync.Mutex
+	defaultBroker Broker
+	subscriptions = map[string]Subscription{}
+)
+
+func Init(driver, brokers, groupID string, security Security) error {
+	b, err := NewBroker(driver, brokers, groupID, security)
+	if err != nil {
+		return err
+	}
+
+	SetBroker(b)
+	return nil
+}
+
+func SetBroker(b Broker) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	defaultBroker = b
+}
+
+func GetBroker() Broker {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return defaultBroker
+}
+
+func Produce(topic string, messages []string) error {
+	b := GetBroker()
+	if b == nil {
+		return ErrNoBroker
+	}
+
+	for _, message := range messages {
+		if err := b.Produce(context.Background(), &Message{Topic: topic, Value: []byte(message)}); err != nil {
+			log.Printf("Failed to produce message to %s: %v", topic, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// ProduceMessage enqueues a single prepared message on the default broker.
+func ProduceMessage(ctx context.Context, msg *Message) error {
+	b := GetBroker()
+	if b == nil {
+		return ErrNoBroker
+	}
+	return b.Produce(ctx, msg)
+}
+
+// ProduceMessageSync produces msg on the default broker and waits for its
+// delivery report until ctx ends.
+func ProduceMessageSync(ctx context.Context, msg *Message) error {
+	b := GetBroker()
+	if b == nil {
+		return ErrNoBroker
+	}
+	return ProduceSync(ctx, b, msg)
+}
+
+// ProduceMessageAsync produces msg on the default broker and reports its
+// delivery to onDelivery.
+func ProduceMessageAsync(ctx context.Context, msg *Message, onDelivery DeliveryFunc) error {
+	b := GetBroker()
+	if b == nil {
+		return ErrNoBroker
+	}
+	return b.ProduceAsync(ctx, msg, onDelivery)
+}
+
+// Consume polls the given topics up to attempts times within timeout and
+// returns the first message received, committing it. It returns nil when
+// nothing arrived in time.
+func Consume(topics []string, attempts int, timeout time.Duration) *Message {
+	sub, err := subscription(topics)
+	if err != nil {
+		log.Printf("Failed to subscribe to %v: %v", topics, err)
+		return nil
+	}
+
+	if attempts < 1 {
+		attempts = 1
+	}
+	interval := timeout / time.Duration(attempts)
+
+	for i := 0; i < attempts; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		msg, err := sub.Fetch(ctx)
+		cancel()
+
+		if err == nil {
+			if err := sub.Commit(context.Background(), msg); err != nil {
+				log.Printf("Failed to commit offset for %s: %v", msg.Topic, err)
+			}
+			return msg
+		}
+		if err != context.DeadlineExceeded {
+			log.Printf("Failed to consume from %v: %v", topics, err)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func subscription(topics []string) (Subscription, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if defaultBroker == nil {
+		return nil, ErrNoBroker
+	}
+
+	sorted := append([]string(nil), topics...)
+	sort.Strings(sorted)
+	key := strings.Join(sorted, ",")
+
+	if sub, ok := subscriptions[key]; ok {
+		return sub, nil
+	}
+
+	sub, err := defaultBroker.Subscribe(sorted, SubscribeOptions{})
+	if err != nil {
+		return nil, err
+	}
+	subscriptions[key] = sub
+	return sub, nil
+}
+
+func Close() error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for key, sub := range subscriptions {
+		if err := sub.Close(); err != nil {
+			log.Printf("Failed to close subscription %s: %v", key, err)
+		}
+		delete(subscriptions, key)
+	}
+
+	if defaultBroker == nil {
+		return nil
+	}
+
+	err := defaultBroker.Close()
+	defaultBroker = nil
+	return err
+}
@@ -0,0 +1,203 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryBroker is an in-process Broker with a single partition per topic.
+// Subscriptions of the same consumer group share a cursor, so each message
+// is delivered to one of them and redelivered if it was never committed.
+type MemoryBroker struct {
+	mu      sync.Mutex
+	topics  map[string][]*Message
+	cursors map[cursorKey]*memoryCursor
+	notify  chan struct{}
+	closed  bool
+}
+
+type cursorKey struct {
+	group string
+	topic string
+}
+
+// memoryCursor tracks a group on a topic. committed is where the group
+// resumes, FromLatest sets it without the group having committed anything.
+type memoryCursor struct {
+	next         int64
+	committed    int64
+	hasCommitted bool
+	members      int
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		topics:  make(map[string][]*Message),
+		cursors: make(map[cursorKey]*memoryCursor),
+		notify:  make(chan struct{}),
+	}
+}
+
+func (b *MemoryBroker) Produce(ctx context.Context, msg *Message) error {
+	return b.ProduceAsync(ctx, msg, nil)
+}
+
+// ProduceAsync stores the message right away, so its delivery report is
+// always a success.
+func (b *MemoryBroker) ProduceAsync(ctx context.Context, msg *Message, onDelivery DeliveryFunc) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrBrokerClosed
+	}
+
+	stored := *msg
+	stored.Partition = 0
+	stored.Offset = int64(len(b.topics[msg.Topic]))
+	stored.Timestamp = time.Now()
+	b.topics[msg.Topic] = append(b.topics[msg.Topic], &stored)
+
+	b.broadcast()
+	b.mu.Unlock()
+
+	deliveries.produced(msg.Topic)
+	deliveries.delivered(msg.Topic, nil)
+	if onDelivery != nil {
+		report := stored
+		onDelivery(&report, nil)
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(topics []string, opts SubscribeOptions) (Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrBrokerClosed
+	}
+
+	for _, topic := range topics {
+		key := cursorKey{group: opts.GroupID, topic: topic}
+		if _, ok := b.cursors[key]; !ok && opts.FromLatest {
+			end := int64(len(b.topics[topic]))
+			b.cursors[key] = &memoryCursor{next: end, committed: end}
+		}
+		b.cursor(opts.GroupID, topic).members++
+	}
+
+	return &memorySubscription{broker: b, group: opts.GroupID, topics: append([]string(nil), topics...)}, nil
+}
+
+func (b *MemoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.closed {
+		b.closed = true
+		b.broadcast()
+	}
+	return nil
+}
+
+// cursor must be called with b.mu held.
+func (b *MemoryBroker) cursor(group, topic string) *memoryCursor {
+	key := cursorKey{group: group, topic: topic}
+
+	c, ok := b.cursors[key]
+	if !ok {
+		c = &memoryCursor{}
+		b.cursors[key] = c
+	}
+	return c
+}
+
+// broadcast must be called with b.mu held.
+func (b *MemoryBroker) broadcast() {
+	close(b.notify)
+	b.notify = make(chan struct{})
+}
+
+type memorySubscription struct {
+	broker *MemoryBroker
+	group  string
+	topics []string
+	once   sync.Once
+	closed bool
+}
+
+func (s *memorySubscription) Fetch(ctx context.Context) (*Message, error) {
+	b := s.broker
+
+	for {
+		b.mu.Lock()
+		if b.closed || s.closed {
+			b.mu.Unlock()
+			return nil, ErrBrokerClosed
+		}
+
+		for _, topic := range s.topics {
+			c := b.cursor(s.group, topic)
+			log := b.topics[topic]
+			if c.next < int64(len(log)) {
+				msg := *log[c.next]
+				c.next++
+				b.mu.Unlock()
+				return &msg, nil
+			}
+		}
+
+		wait := b.notify
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-wait:
+		}
+	}
+}
+
+func (s *memorySubscription) Commit(ctx context.Context, msg *Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b := s.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.cursor(s.group, msg.Topic)
+	if msg.Offset+1 > c.committed {
+		c.committed = msg.Offset + 1
+	}
+	c.hasCommitted = true
+	return nil
+}
+
+func (s *memorySubscription) Close() error {
+	s.once.Do(func() {
+		b := s.broker
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		s.closed = true
+		for _, topic := range s.topics {
+			c := b.cursor(s.group, topic)
+			c.members--
+			// Without remaining members the group rebalances and resumes
+			// from the last committed offset.
+			if c.members <= 0 {
+				c.members = 0
+				c.next = c.committed
+			}
+		}
+		b.broadcast()
+	})
+	return nil
+}
@@ -0,0 +1,62 @@
+package kafka
+
+import "sync"
+
+// DeliveryStats counts produced messages and their delivery reports.
+// Pending messages are produced but not yet confirmed either way.
+type DeliveryStats struct {
+	Produced  int64 `json:"produced"`
+	Delivered int64 `json:"delivered"`
+	Failed    int64 `json:"failed"`
+	Pending   int64 `json:"pending"`
+}
+
+type deliveryMetrics struct {
+	mu     sync.Mutex
+	topics map[string]*DeliveryStats
+}
+
+var deliveries = &deliveryMetrics{topics: make(map[string]*DeliveryStats)}
+
+func (m *deliveryMetrics) topic(topic string) *DeliveryStats {
+	stats, ok := m.topics[topic]
+	if !ok {
+		stats = &DeliveryStats{}
+		m.topics[topic] = stats
+	}
+	return stats
+}
+
+func (m *deliveryMetrics) produced(topic string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.topic(topic)
+	stats.Produced++
+	stats.Pending++
+}
+
+func (m *deliveryMetrics) delivered(topic string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.topic(topic)
+	stats.Pending--
+	if err != nil {
+		stats.Failed++
+	} else {
+		stats.Delivered++
+	}
+}
+
+// Deliveries returns the delivery counters per topic since startup.
+func Deliveries() map[string]DeliveryStats {
+	deliveries.mu.Lock()
+	defer deliveries.mu.Unlock()
+
+	stats := make(map[string]DeliveryStats, len(deliveries.topics))
+	for topic, s := range deliveries.topics {
+		stats[topic] = *s
+	}
+	return stats
+}
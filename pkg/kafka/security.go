@@ -0,0 +1,47 @@
+package kafka
+
+import ckafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+
+// Security configures how connections to the brokers are authenticated and
+// encrypted. The zero value connects over PLAINTEXT.
+type Security struct {
+	// Protocol is PLAINTEXT, SSL, SASL_PLAINTEXT or SASL_SSL.
+	Protocol string
+
+	// SASLMechanism is PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512.
+	SASLMechanism string
+	SASLUsername  string
+	SASLPassword  string
+
+	// CALocation is a PEM bundle used to verify the brokers, the system
+	// store is used when empty.
+	CALocation string
+	// CertLocation and KeyLocation hold a PEM client certificate for
+	// clusters that require mutual TLS.
+	CertLocation string
+	KeyLocation  string
+	KeyPassword  string
+}
+
+// configMap returns the librdkafka properties of s, leaving out the unset
+// ones so librdkafka keeps its defaults.
+func (s Security) configMap() ckafka.ConfigMap {
+	cfg := ckafka.ConfigMap{}
+
+	set := func(key, value string) {
+		if value != "" {
+			cfg[key] = value
+		}
+	}
+
+	set("security.protocol", s.Protocol)
+	set("sasl.mechanism", s.SASLMechanism)
+	set("sasl.username", s.SASLUsername)
+	set("sasl.password", s.SASLPassword)
+	set("ssl.ca.location", s.CALocation)
+	set("ssl.certificate.location", s.CertLocation)
+	set("ssl.key.location", s.KeyLocation)
+	set("ssl.key.password", s.KeyPassword)
+
+	return cfg
+}
@@ -0,0 +1,251 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	retryDelay = 5 * time.Second
+
+	dlqSuffix = ".dlq"
+
+	// Headers added to dead-lettered messages.
+	HeaderDLQError       = "dlq-error"
+	HeaderDLQTopic       = "dlq-source-topic"
+	HeaderDLQPartition   = "dlq-source-partition"
+	HeaderDLQOffset      = "dlq-source-offset"
+	HeaderDLQAttempts    = "dlq-attempts"
+	HeaderDLQFailedAt    = "dlq-failed-at"
+	HeaderDLQReplayCount = "dlq-replay-count"
+)
+
+// DLQTopic is the dead-letter topic of topic.
+func DLQTopic(topic string) string {
+	return topic + dlqSuffix
+}
+
+// DLQSourceTopic is the topic whose dead letters go to dlqTopic.
+func DLQSourceTopic(dlqTopic string) string {
+	return strings.TrimSuffix(dlqTopic, dlqSuffix)
+}
+
+func IsDLQTopic(topic string) bool {
+	return strings.HasSuffix(topic, dlqSuffix)
+}
+
+// RetryPolicy controls how often a failing message is retried before it is
+// sent to the dead-letter topic.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    5,
+	InitialBackoff: time.Second,
+	MaxBackoff:     time.Minute,
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, p.MaxBackoff)
+}
+
+type HandlerOptions struct {
+	Concurrency int
+	// Retry defaults to DefaultRetryPolicy.
+	Retry *RetryPolicy
+}
+
+// HandlerFunc processes one message. The message is committed once it
+// returns nil, or once it was dead-lettered after a permanent error or too
+// many attempts.
+type HandlerFunc func(ctx context.Context, msg *Message) error
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks a handler error that retrying cannot fix, such as a
+// malformed message.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+func IsPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
+
+type route struct {
+	handler     HandlerFunc
+	concurrency int
+	retry       RetryPolicy
+}
+
+// Workers consumes registered topics in the background. Every worker has
+// its own subscription in the group, so the broker spreads partitions
+// across the workers of a topic.
+type Workers struct {
+	broker  Broker
+	groupID string
+	routes  map[string]route
+}
+
+func NewWorkers(broker Broker, groupID string) *Workers {
+	return &Workers{broker: broker, groupID: groupID, routes: make(map[string]route)}
+}
+
+func (w *Workers) Handle(topic string, opts HandlerOptions, handler HandlerFunc) {
+	retry := DefaultRetryPolicy
+	if opts.Retry != nil {
+		retry = *opts.Retry
+	}
+	retry.MaxAttempts = max(retry.MaxAttempts, 1)
+
+	w.routes[topic] = route{handler: handler, concurrency: max(opts.Concurrency, 1), retry: retry}
+}
+
+// Start launches the workers. They stop and close their subscriptions when
+// ctx is done, and wg tracks them until then.
+func (w *Workers) Start(ctx context.Context, wg *sync.WaitGroup) error {
+	for topic, rt := range w.routes {
+		for i := 0; i < rt.concurrency; i++ {
+			sub, err := w.broker.Subscribe([]string{topic}, SubscribeOptions{GroupID: w.groupID})
+			if err != nil {
+				return fmt.Errorf("kafka: unable to subscribe worker to %s: %w", topic, err)
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w.run(ctx, sub, rt)
+			}()
+		}
+	}
+	return nil
+}
+
+func (w *Workers) run(ctx context.Context, sub Subscription, rt route) {
+	defer sub.Close()
+
+	for {
+		msg, err := sub.Fetch(ctx)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, ErrBrokerClosed) {
+				return
+			}
+			log.Printf("Worker failed to fetch: %v", err)
+			if !sleep(ctx, retryDelay) {
+				return
+			}
+			continue
+		}
+
+		if !w.process(ctx, rt, msg) {
+			return
+		}
+
+		if err := sub.Commit(ctx, msg); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to commit offset for %s: %v", msg.Topic, err)
+		}
+	}
+}
+
+// process handles msg with retries and dead-letters it when that fails,
+// returning false when ctx ends first.
+func (w *Workers) process(ctx context.Context, rt route, msg *Message) bool {
+	attempt := 1
+	for {
+		err := safeHandle(ctx, rt.handler, msg)
+		if err == nil {
+			return true
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+
+		// Dead letters are never dead-lettered again, they are retried
+		// until they succeed unless they can never succeed.
+		dlq := IsDLQTopic(msg.Topic)
+		switch {
+		case IsPermanent(err) && dlq:
+			log.Printf("Dropping dead letter %s[%d]@%d: %v", msg.Topic, msg.Partition, msg.Offset, err)
+			return true
+		case IsPermanent(err), !dlq && attempt >= rt.retry.MaxAttempts:
+			return w.deadLetter(ctx, msg, err, attempt)
+		}
+
+		delay := rt.retry.backoff(attempt)
+		log.Printf("Failed to handle %s[%d]@%d (attempt %d), retrying in %s: %v", msg.Topic, msg.Partition, msg.Offset, attempt, delay, err)
+		if !sleep(ctx, delay) {
+			return false
+		}
+		attempt++
+	}
+}
+
+// deadLetter moves msg to its dead-letter topic, retrying until that
+// succeeds.
+func (w *Workers) deadLetter(ctx context.Context, msg *Message, cause error, attempts int) bool {
+	headers := make(map[string]string, len(msg.Headers)+6)
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[HeaderDLQError] = cause.Error()
+	headers[HeaderDLQTopic] = msg.Topic
+	headers[HeaderDLQPartition] = strconv.Itoa(int(msg.Partition))
+	headers[HeaderDLQOffset] = strconv.FormatInt(msg.Offset, 10)
+	headers[HeaderDLQAttempts] = strconv.Itoa(attempts)
+	headers[HeaderDLQFailedAt] = time.Now().UTC().Format(time.RFC3339Nano)
+
+	dead := &Message{Topic: DLQTopic(msg.Topic), Key: msg.Key, Value: msg.Value, Headers: headers}
+
+	for {
+		err := ProduceSync(ctx, w.broker, dead)
+		if err == nil {
+			log.Printf("Moved %s[%d]@%d to %s after %d attempt(s): %v", msg.Topic, msg.Partition, msg.Offset, dead.Topic, attempts, cause)
+			return true
+		}
+
+		log.Printf("Failed to dead-letter %s[%d]@%d, retrying: %v", msg.Topic, msg.Partition, msg.Offset, err)
+		if !sleep(ctx, retryDelay) {
+			return false
+		}
+	}
+}
+
+func safeHandle(ctx context.Context, handler HandlerFunc, msg *Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+	return handler(ctx, msg)
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
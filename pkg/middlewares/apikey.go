@@ -0,0 +1,63 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+	"web-service/pkg/data"
+)
+
+const (
+	APIKeyHeader = "X-API-Key"
+
+	lastUsedInterval = time.Minute
+)
+
+// APIKeyMiddleware authenticates requests carrying an X-API-Key header,
+// granting the key's scopes as roles.
+func APIKeyMiddleware(repo data.APIKeyRepository) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			plaintext := r.Header.Get(APIKeyHeader)
+			if plaintext == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key, err := repo.GetByHash(r.Context(), data.HashAPIKey(plaintext))
+			if errors.Is(err, data.ErrAPIKeyNotFound) {
+				unauthorized(w, "Invalid API key")
+				return
+			}
+			if err != nil {
+				log.Printf("Failed to look up API key: %v", err)
+				unauthorized(w, "Unable to verify API key")
+				return
+			}
+
+			now := time.Now().UTC()
+			if !key.Active(now) {
+				unauthorized(w, "API key is revoked or expired")
+				return
+			}
+
+			if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > lastUsedInterval {
+				go touchAPIKey(repo, key.ID, now)
+			}
+
+			principal := &Principal{Subject: "apikey:" + key.ID, Roles: key.Scopes}
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+func touchAPIKey(repo data.APIKeyRepository, id string, at time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := repo.TouchLastUsed(ctx, id, at); err != nil {
+		log.Printf("Failed to record API key usage: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+	"web-service/pkg/data"
+	"web-service/pkg/kafka"
+)
+
+const (
+	pollInterval = time.Second
+	// lease hides a claimed message from other relays while it is produced.
+	lease       = 30 * time.Second
+	baseBackoff = time.Second
+	maxBackoff  = 5 * time.Minute
+	markTimeout = 5 * time.Second
+	// produceTimeout bounds the wait for a delivery report.
+	produceTimeout = 10 * time.Second
+)
+
+// Relay publishes pending outbox messages to Kafka and marks them sent once
+// the broker acknowledged them. Messages are delivered at least once: a
+// crash between the acknowledgement and marking a message sent publishes it
+// again after the lease ends.
+type Relay struct {
+	repo    data.OutboxRepository
+	produce func(ctx context.Context, msg *kafka.Message) error
+}
+
+func NewRelay(repo data.OutboxRepository) *Relay {
+	return &Relay{repo: repo, produce: kafka.ProduceMessageSync}
+}
+
+// Run relays messages until ctx is done.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		r.drain(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Relay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		message, err := r.repo.Claim(ctx, time.Now().UTC(), lease)
+		if errors.Is(err, data.ErrOutboxEmpty) {
+			return
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Failed to claim outbox message: %v", err)
+			}
+			return
+		}
+
+		r.relay(ctx, message)
+	}
+}
+
+func (r *Relay) relay(ctx context.Context, message data.OutboxMessage) {
+	produceCtx, cancelProduce := context.WithTimeout(ctx, produceTimeout)
+	err := r.produce(produceCtx, &kafka.Message{
+		Topic:   message.Topic,
+		Key:     message.Key,
+		Value:   message.Value,
+		Headers: message.Headers,
+	})
+	cancelProduce()
+
+	// Record the outcome even when shutdown interrupted the attempt.
+	markCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), markTimeout)
+	defer cancel()
+
+	if err != nil {
+		next := time.Now().UTC().Add(backoff(message.Attempts))
+		log.Printf("Failed to relay outbox message %s to %s (attempt %d): %v", message.ID, message.Topic, message.Attempts+1, err)
+
+		if err := r.repo.MarkFailed(markCtx, message.ID, err.Error(), next); err != nil {
+			log.Printf("Failed to reschedule outbox message %s: %v", message.ID, err)
+		}
+		return
+	}
+
+	if err := r.repo.MarkSent(markCtx, message.ID); err != nil {
+		log.Printf("Failed to mark outbox message %s as sent: %v", message.ID, err)
+	}
+}
+
+func backoff(attempts int) time.Duration {
+	d := baseBackoff
+	for i := 0; i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	googledrive "web-service/pkg/google-drive"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+const driveFields = "id, name, mimeType, size, modifiedTime"
+
+// DriveStorage stores objects in one user's Google Drive. Keys are Drive
+// file IDs and Folder is a parent folder ID.
+type DriveStorage struct {
+	srv *drive.Service
+}
+
+func NewDriveStorage(srv *drive.Service) *DriveStorage {
+	return &DriveStorage{srv: srv}
+}
+
+// DriveProvider returns a Provider that opens the calling user's Drive.
+func DriveProvider(tokens *googledrive.TokenSources) Provider {
+	return func(ctx context.Context, userID string) (Storage, error) {
+		if !googledrive.Enabled() {
+			return nil, googledrive.ErrNotConfigured
+		}
+
+		client, err := tokens.Client(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		srv, err := drive.New(client)
+		if err != nil {
+			return nil, err
+		}
+		return NewDriveStorage(srv), nil
+	}
+}
+
+func (s *DriveStorage) Put(ctx context.Context, name string, r io.Reader, opts PutOptions) (Object, error) {
+	file := &drive.File{Name: name, MimeType: opts.ContentType}
+	if opts.Folder != "" {
+		file.Parents = []string{opts.Folder}
+	}
+
+	created, err := s.srv.Files.Create(file).Media(r).Fields(driveFields).Context(ctx).Do()
+	if err != nil {
+		return Object{}, mapDriveError(err)
+	}
+	return driveObject(created), nil
+}
+
+func (s *DriveStorage) Get(ctx context.Context, key string) (io.ReadCloser, Object, error) {
+	obj, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, Object{}, err
+	}
+
+	resp, err := s.srv.Files.Get(key).Context(ctx).Download()
+	if err != nil {
+		return nil, Object{}, mapDriveError(err)
+	}
+	return resp.Body, obj, nil
+}
+
+func (s *DriveStorage) Delete(ctx context.Context, key string) error {
+	return mapDriveError(s.srv.Files.Delete(key).Context(ctx).Do())
+}
+
+// List returns files whose name starts with prefix.
+func (s *DriveStorage) List(ctx context.Context, prefix string) ([]Object, error) {
+	call := s.srv.Files.List().
+		Fields(googleapi.Field("nextPageToken, files(" + driveFields + ")")).
+		Q("trashed = false")
+	if prefix != "" {
+		call = call.Q(fmt.Sprintf("trashed = false and name contains '%s'", strings.ReplaceAll(prefix, "'", `\'`)))
+	}
+
+	objects := []Object{}
+	err := call.Pages(ctx, func(list *drive.FileList) error {
+		for _, file := range list.Files {
+			if strings.HasPrefix(file.Name, prefix) {
+				objects = append(objects, driveObject(file))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, mapDriveError(err)
+	}
+	return objects, nil
+}
+
+func (s *DriveStorage) Stat(ctx context.Context, key string) (Object, error) {
+	file, err := s.srv.Files.Get(key).Fields(driveFields).Context(ctx).Do()
+	if err != nil {
+		return Object{}, mapDriveError(err)
+	}
+	return driveObject(file), nil
+}
+
+func driveObject(file *drive.File) Object {
+	modified, _ := time.Parse(time.RFC3339, file.ModifiedTime)
+
+	return Object{
+		Key:         file.Id,
+		Name:        file.Name,
+		Size:        file.Size,
+		ContentType: file.MimeType,
+		ModifiedAt:  modified.UTC(),
+	}
+}
+
+func mapDriveError(err error) error {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+		return ErrNotFound
+	}
+	return err
+}
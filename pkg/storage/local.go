@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type LocalStorage struct {
+	root string
+}
+
+func NewLocalStorage(root string) (*LocalStorage, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("unable to create storage directory: %w", err)
+	}
+	return &LocalStorage{root: root}, nil
+}
+
+// path resolves key inside the root, rejecting keys that escape it.
+func (s *LocalStorage) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" {
+		return "", ErrNotFound
+	}
+	return filepath.Join(s.root, filepath.FromSlash(clean)), nil
+}
+
+func (s *LocalStorage) Put(ctx context.Context, name string, r io.Reader, opts PutOptions) (Object, error) {
+	key, err := newKey(opts.Folder, name)
+	if err != nil {
+		return Object{}, err
+	}
+
+	target, err := s.path(key)
+	if err != nil {
+		return Object{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return Object{}, err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return Object{}, err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return Object{}, err
+	}
+	if err := f.Close(); err != nil {
+		return Object{}, err
+	}
+
+	if err := os.Rename(f.Name(), target); err != nil {
+		return Object{}, err
+	}
+
+	return s.Stat(ctx, key)
+}
+
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, Object, error) {
+	obj, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, Object{}, err
+	}
+
+	target, _ := s.path(key)
+	f, err := os.Open(target)
+	if err != nil {
+		return nil, Object{}, mapLocalError(err)
+	}
+	return f, obj, nil
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	return mapLocalError(os.Remove(target))
+}
+
+func (s *LocalStorage) List(ctx context.Context, prefix string) ([]Object, error) {
+	objects := []Object{}
+
+	err := filepath.WalkDir(s.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, localObject(key, info))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+func (s *LocalStorage) Stat(ctx context.Context, key string) (Object, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return Object{}, err
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		return Object{}, mapLocalError(err)
+	}
+	if info.IsDir() {
+		return Object{}, ErrNotFound
+	}
+	return localObject(strings.TrimPrefix(path.Clean("/"+key), "/"), info), nil
+}
+
+func localObject(key string, info fs.FileInfo) Object {
+	return Object{
+		Key:         key,
+		Name:        nameFromKey(key),
+		Size:        info.Size(),
+		ContentType: mime.TypeByExtension(path.Ext(key)),
+		ModifiedAt:  info.ModTime().UTC(),
+	}
+}
+
+func mapLocalError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
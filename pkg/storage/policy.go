@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// sniffLen is the number of bytes http.DetectContentType looks at.
+const sniffLen = 512
+
+var (
+	ErrTooLarge       = errors.New("storage: file is too large")
+	ErrTypeNotAllowed = errors.New("storage: file type is not allowed")
+)
+
+// Policy restricts what may be uploaded through a route. Allowed and Denied
+// hold media types such as "application/pdf" or wildcards such as
+// "image/*". Denied wins over Allowed, and an empty Allowed permits every
+// type that is not denied.
+type Policy struct {
+	MaxSize int64
+	Allowed []string
+	Denied  []string
+}
+
+// CheckSize rejects sizes above MaxSize. A MaxSize of 0 disables the check.
+func (p Policy) CheckSize(size int64) error {
+	if p.MaxSize > 0 && size > p.MaxSize {
+		return fmt.Errorf("%w: %d bytes exceeds the limit of %d bytes", ErrTooLarge, size, p.MaxSize)
+	}
+	return nil
+}
+
+func (p Policy) CheckType(contentType string) error {
+	mediaType := baseMediaType(contentType)
+
+	for _, pattern := range p.Denied {
+		if matchMediaType(pattern, mediaType) {
+			return fmt.Errorf("%w: %s", ErrTypeNotAllowed, mediaType)
+		}
+	}
+
+	if len(p.Allowed) == 0 {
+		return nil
+	}
+	for _, pattern := range p.Allowed {
+		if matchMediaType(pattern, mediaType) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrTypeNotAllowed, mediaType)
+}
+
+// DetectContentType sniffs the content type from the first bytes of r. The
+// returned reader replays those bytes, so it must be used instead of r.
+func DetectContentType(r io.Reader) (string, io.Reader, error) {
+	br := bufio.NewReaderSize(r, sniffLen)
+
+	head, err := br.Peek(sniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return "", nil, err
+	}
+	return http.DetectContentType(head), br, nil
+}
+
+func baseMediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return mediaType
+}
+
+func matchMediaType(pattern, mediaType string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == "*/*" {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return strings.HasPrefix(mediaType, prefix+"/")
+	}
+	return pattern == mediaType
+}
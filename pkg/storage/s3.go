@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+}
+
+// S3Storage works with any S3-compatible service, including MinIO.
+type S3Storage struct {
+	client *minio.Client
+	bucket string
+}
+
+func NewS3Storage(ctx context.Context, cfg S3Config) (*S3Storage, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("unable to check bucket %s: %w", cfg.Bucket, err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, cfg.Bucket, minio.MakeBucketOptions{Region: cfg.Region}); err != nil {
+			return nil, fmt.Errorf("unable to create bucket %s: %w", cfg.Bucket, err)
+		}
+	}
+
+	return &S3Storage{client: client, bucket: cfg.Bucket}, nil
+}
+
+func (s *S3Storage) Put(ctx context.Context, name string, r io.Reader, opts PutOptions) (Object, error) {
+	key, err := newKey(opts.Folder, name)
+	if err != nil {
+		return Object{}, err
+	}
+
+	size := opts.Size
+	if size == 0 {
+		size = -1
+	}
+
+	_, err = s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType:  opts.ContentType,
+		UserMetadata: map[string]string{"original-name": name},
+	})
+	if err != nil {
+		return Object{}, err
+	}
+
+	return s.Stat(ctx, key)
+}
+
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, Object, error) {
+	obj, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, Object{}, err
+	}
+
+	reader, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, Object{}, mapS3Error(err)
+	}
+	return reader, obj, nil
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	if _, err := s.Stat(ctx, key); err != nil {
+		return err
+	}
+	return mapS3Error(s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}))
+}
+
+func (s *S3Storage) List(ctx context.Context, prefix string) ([]Object, error) {
+	objects := []Object{}
+
+	for info := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if info.Err != nil {
+			return nil, info.Err
+		}
+		objects = append(objects, s3Object(info))
+	}
+	return objects, nil
+}
+
+func (s *S3Storage) Stat(ctx context.Context, key string) (Object, error) {
+	info, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return Object{}, mapS3Error(err)
+	}
+	return s3Object(info), nil
+}
+
+func s3Object(info minio.ObjectInfo) Object {
+	name := info.UserMetadata["Original-Name"]
+	if name == "" {
+		name = nameFromKey(info.Key)
+	}
+
+	return Object{
+		Key:         info.Key,
+		Name:        name,
+		Size:        info.Size,
+		ContentType: info.ContentType,
+		ModifiedAt:  info.LastModified.UTC(),
+	}
+}
+
+func mapS3Error(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NotFound":
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+var ErrNotFound = errors.New("storage: object not found")
+
+type Object struct {
+	Key         string    `json:"key"`
+	Name        string    `json:"name"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"contentType,omitempty"`
+	ModifiedAt  time.Time `json:"modifiedAt"`
+}
+
+type PutOptions struct {
+	ContentType string
+	// Size is the content length, or -1 when unknown.
+	Size int64
+	// Folder is a backend specific location, such as a Drive folder ID or
+	// a key prefix.
+	Folder string
+}
+
+// Storage stores uploaded files. Keys are assigned by the backend on Put.
+type Storage interface {
+	Put(ctx context.Context, name string, r io.Reader, opts PutOptions) (Object, error)
+	Get(ctx context.Context, key string) (io.ReadCloser, Object, error)
+	Delete(ctx context.Context, key string) error
+	List(ctx context.Context, prefix string) ([]Object, error)
+	Stat(ctx context.Context, key string) (Object, error)
+}
+
+// Provider resolves the backend used for a user's request.
+type Provider func(ctx context.Context, userID string) (Storage, error)
+
+func Shared(s Storage) Provider {
+	return func(ctx context.Context, userID string) (Storage, error) {
+		return s, nil
+	}
+}
+
+// newKey builds a unique, path-safe key that keeps the original file name
+// readable.
+func newKey(folder, name string) (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	key := hex.EncodeToString(b) + "-" + sanitizeName(name)
+	if folder = strings.Trim(folder, "/"); folder != "" {
+		key = folder + "/" + key
+	}
+	return key, nil
+}
+
+func sanitizeName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" || name == ".." {
+		return "file"
+	}
+	return name
+}
+
+// nameFromKey strips the random prefix added by newKey.
+func nameFromKey(key string) string {
+	base := path.Base(key)
+	if _, name, ok := strings.Cut(base, "-"); ok {
+		return name
+	}
+	return base
+}
@@ -0,0 +1,39 @@
+package utils
+
+import "encoding/json"
+
+// MergePatch applies a JSON Merge Patch (RFC 7396) to the original document.
+func MergePatch(original, patch []byte) ([]byte, error) {
+	var target, changes interface{}
+
+	if err := json.Unmarshal(original, &target); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(mergePatch(target, changes))
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+
+	return targetObject
+}
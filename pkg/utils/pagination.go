@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+type Pagination struct {
+	Total      int64  `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	PrevCursor string `json:"prevCursor,omitempty"`
+}
+
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+type cursor struct {
+	Offset int `json:"o"`
+}
+
+func EncodeCursor(offset int) string {
+	b, _ := json.Marshal(cursor{Offset: offset})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(value string) (int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.Offset < 0 {
+		return 0, ErrInvalidCursor
+	}
+	return c.Offset, nil
+}
+
+// ParsePage reads limit, offset and cursor query parameters. A cursor takes
+// precedence over offset.
+func ParsePage(query url.Values) (Page, error) {
+	page := Page{Limit: DefaultPageLimit}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return Page{}, errors.New("limit must be a positive integer")
+		}
+		page.Limit = min(limit, MaxPageLimit)
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		offset, err := DecodeCursor(value)
+		if err != nil {
+			return Page{}, err
+		}
+		page.Offset = offset
+		return page, nil
+	}
+
+	if value := query.Get("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return Page{}, errors.New("offset must be a non-negative integer")
+		}
+		page.Offset = offset
+	}
+
+	return page, nil
+}
+
+// ParseSort reads a sort parameter such as "name,-id", rejecting fields
+// that are not in allowed.
+func ParseSort(value string, allowed ...string) ([]SortField, error) {
+	var fields []SortField
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		field := SortField{Field: part}
+		if strings.HasPrefix(part, "-") {
+			field = SortField{Field: part[1:], Desc: true}
+		}
+
+		valid := false
+		for _, name := range allowed {
+			if field.Field == name {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("cannot sort by %q", field.Field)
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+func NewPagination(page Page, total int64) Pagination {
+	p := Pagination{Total: total, Limit: page.Limit, Offset: page.Offset}
+
+	if int64(page.Offset+page.Limit) < total {
+		p.NextCursor = EncodeCursor(page.Offset + page.Limit)
+	}
+	if page.Offset > 0 {
+		p.PrevCursor = EncodeCursor(max(page.Offset-page.Limit, 0))
+	}
+	return p
+}
+
+// SetLinkHeader writes RFC 8288 next/prev links for the current request.
+func SetLinkHeader(w http.ResponseWriter, r *http.Request, p Pagination) {
+	var links []string
+
+	link := func(cursor, rel string) {
+		u := *r.URL
+		query := u.Query()
+		query.Del("offset")
+		query.Set("cursor", cursor)
+		u.RawQuery = query.Encode()
+		links = append(links, fmt.Sprintf("<%s>; rel=\"%s\"", u.RequestURI(), rel))
+	}
+
+	if p.NextCursor != "" {
+		link(p.NextCursor, "next")
+	}
+	if p.PrevCursor != "" {
+		link(p.PrevCursor, "prev")
+	}
+
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
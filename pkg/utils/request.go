@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"web-service/pkg/validator"
+)
+
+const MaxJSONBodySize = 1 << 20
+
+// ReadBody reads the request body, failing with *http.MaxBytesError when it
+// exceeds MaxJSONBodySize.
+func ReadBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	defer r.Body.Close()
+
+	return io.ReadAll(http.MaxBytesReader(w, r.Body, MaxJSONBodySize))
+}
+
+// DecodeJSON strictly decodes the request body into dst and validates it.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+	body, err := ReadBody(w, r)
+	if err != nil {
+		return err
+	}
+	return DecodeJSONBytes(body, dst)
+}
+
+func DecodeJSONBytes(body []byte, dst interface{}) error {
+	decode := json.NewDecoder(bytes.NewReader(body))
+	decode.DisallowUnknownFields()
+
+	if err := decode.Decode(dst); err != nil {
+		return err
+	}
+	return validator.Struct(dst)
+}
+
+// DecodeErrorResponse maps errors from DecodeJSON to a response.
+func DecodeErrorResponse(err error) Response {
+	var validationErrs validator.Errors
+	if errors.As(err, &validationErrs) {
+		return ValidationError("Validation failed", validationErrs)
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return PayloadTooLargeError("Request body is too large")
+	}
+
+	return BadRequestError(JSONDecodeError(err), nil)
+}
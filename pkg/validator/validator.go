@@ -0,0 +1,130 @@
+package validator
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Errors maps JSON field names to a description of the failed rule.
+type Errors map[string]string
+
+func (e Errors) Error() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		parts = append(parts, field+": "+e[field])
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Struct checks the `validate` tags of v, which must be a struct or a
+// pointer to one. Supported rules are required, min=N and max=N; min and
+// max bound the value of numbers and the length of strings and slices.
+// It returns nil when every rule passes.
+func Struct(v interface{}) error {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		return fmt.Errorf("validator: expected struct, got %s", value.Kind())
+	}
+
+	errs := Errors{}
+	typ := value.Type()
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("validate")
+		if tag == "" || !field.IsExported() {
+			continue
+		}
+
+		name := fieldName(field)
+		for _, rule := range strings.Split(tag, ",") {
+			if msg := check(value.Field(i), rule); msg != "" {
+				errs[name] = msg
+				break
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func fieldName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	return field.Name
+}
+
+func check(value reflect.Value, rule string) string {
+	name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+
+	switch name {
+	case "required":
+		if isEmpty(value) {
+			return "is required"
+		}
+	case "min", "max":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			panic(fmt.Sprintf("validator: invalid %s rule %q", name, rule))
+		}
+
+		size, isLength := measure(value)
+		if name == "min" && size < limit {
+			if isLength {
+				return fmt.Sprintf("must be at least %s characters", param)
+			}
+			return fmt.Sprintf("must be greater than or equal to %s", param)
+		}
+		if name == "max" && size > limit {
+			if isLength {
+				return fmt.Sprintf("must be at most %s characters", param)
+			}
+			return fmt.Sprintf("must be less than or equal to %s", param)
+		}
+	default:
+		panic(fmt.Sprintf("validator: unknown rule %q", rule))
+	}
+	return ""
+}
+
+// isEmpty reports whether value is missing for the required rule. Blank
+// strings and empty slices and maps count as missing.
+func isEmpty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(value.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	}
+	return value.IsZero()
+}
+
+func measure(value reflect.Value) (float64, bool) {
+	switch value.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(value.String())), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(value.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), false
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), false
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), false
+	}
+	panic(fmt.Sprintf("validator: cannot measure %s", value.Kind()))
+}